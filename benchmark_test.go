@@ -2,7 +2,6 @@ package humanlog
 
 import (
 	"bytes"
-	"compress/gzip"
 	"context"
 	"io"
 	"io/fs"
@@ -49,11 +48,12 @@ func BenchmarkHarness(b *testing.B) {
 			require.NoError(bb, err)
 			defer f.Close()
 
-			gzipReader, err := gzip.NewReader(f)
+			decompressed, err := Decompress(f)
 			require.NoError(bb, err)
+			defer decompressed.Close()
 
 			src := bytes.NewBuffer(nil)
-			_, err = io.Copy(src, gzipReader)
+			_, err = io.Copy(src, decompressed)
 			require.NoError(bb, err)
 
 			sink := &NopSink{}
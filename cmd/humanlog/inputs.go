@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/humanlogio/humanlog"
+	followpkg "github.com/humanlogio/humanlog/internal/pkg/follow"
+	"github.com/humanlogio/humanlog/pkg/sink"
+	"github.com/humanlogio/humanlog/pkg/sink/attrsink"
+	"github.com/humanlogio/humanlog/pkg/sink/mergesink"
+	"github.com/humanlogio/humanlog/pkg/sink/syncsink"
+	"github.com/mattn/go-isatty"
+	types "github.com/minitape/api/go/types/v1"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+)
+
+const stdinInputName = "-"
+
+// expandInputArgs resolves the positional arguments given to humanlog
+// into a list of files to read. Each argument can be a path, a glob
+// pattern or `-` for stdin. Patterns that match nothing are an error,
+// since it's most likely a typo, unless `waitForFiles` is set, in which
+// case paths to files that don't exist yet are kept, to be waited for.
+func expandInputArgs(args []string, waitForFiles bool) ([]string, error) {
+	var inputs []string
+	for _, arg := range args {
+		if arg == stdinInputName {
+			inputs = append(inputs, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", arg, err)
+		}
+		if len(matches) == 0 && waitForFiles && !hasGlobMeta(arg) {
+			matches = []string{arg}
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no such file: %q", arg)
+		}
+		inputs = append(inputs, matches...)
+	}
+	return inputs, nil
+}
+
+func hasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// fileAttrs are the attributes that identify which file an event was
+// read from.
+func fileAttrs(path string) []*types.KV {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return []*types.KV{
+		types.KeyVal(string(semconv.LogFilePathKey), types.ValStr(path)),
+		types.KeyVal(string(semconv.LogFileNameKey), types.ValStr(filepath.Base(path))),
+	}
+}
+
+type followMode int
+
+const (
+	noFollow followMode = iota
+	// like `tail -f`
+	followDescriptor
+	// like `tail -F`
+	followName
+)
+
+// scanInputs reads all the inputs into `snk`. Unless they're followed,
+// inputs are read one after the other. Followed inputs never end, so
+// they are all read at the same time. When `mergeWindow` is set, inputs
+// are read at the same time and their events interleaved by timestamp.
+func scanInputs(ctx context.Context, inputs []string, snk sink.Sink, opts *humanlog.HandlerOptions, follow followMode, mergeWindow time.Duration) {
+	scanInput := func(input string, snk sink.Sink) {
+		var err error
+		if input == stdinInputName {
+			err = scanStdin(ctx, snk, opts)
+		} else {
+			err = scanFile(ctx, input, snk, opts, follow)
+		}
+		if err != nil {
+			logerror("scanning caught an error: %v", err)
+		}
+	}
+	if mergeWindow > 0 && len(inputs) > 1 {
+		merge := mergesink.NewMergeSink(snk, mergeWindow)
+		var wg sync.WaitGroup
+		for _, input := range inputs {
+			in := merge.NewInput()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				scanInput(input, in)
+				if err := in.Close(ctx); err != nil {
+					logerror("merging inputs caught an error: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+		if err := merge.Flush(ctx); err != nil {
+			logerror("merging inputs caught an error: %v", err)
+		}
+		return
+	}
+	if follow == noFollow || len(inputs) == 1 {
+		for _, input := range inputs {
+			if ctx.Err() != nil {
+				break
+			}
+			scanInput(input, snk)
+		}
+		return
+	}
+	snk = syncsink.NewSyncSink(snk)
+	var wg sync.WaitGroup
+	for _, input := range inputs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scanInput(input, snk)
+		}()
+	}
+	wg.Wait()
+}
+
+// scanFile reads the file at `path` and sends its events into `snk`,
+// tagging each of them with the file they came from.
+func scanFile(ctx context.Context, path string, snk sink.Sink, opts *humanlog.HandlerOptions, follow followMode) error {
+	var (
+		f   io.ReadCloser
+		err error
+	)
+	switch follow {
+	case followDescriptor, followName:
+		f, err = followpkg.Open(ctx, path, follow == followName, followpkg.DefaultPollInterval)
+	default:
+		f, err = os.Open(path)
+	}
+	if err != nil {
+		return fmt.Errorf("opening %q: %v", path, err)
+	}
+	defer f.Close()
+	src, err := humanlog.Decompress(f)
+	if err != nil {
+		return fmt.Errorf("reading %q: %v", path, err)
+	}
+	defer src.Close()
+	return humanlog.Scan(ctx, src, attrsink.NewAttrSink(snk, fileAttrs(path)...), opts)
+}
+
+// scanStdin reads events from stdin. If stdin is stuck when we're asked
+// to stop, it gets forcibly closed unless it's a TTY.
+func scanStdin(ctx context.Context, snk sink.Sink, opts *humanlog.HandlerOptions) error {
+	in := os.Stdin
+	if isatty.IsTerminal(in.Fd()) {
+		loginfo("reading stdin...")
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+		}
+		logdebug("requested to stop scanning")
+		select {
+		case <-done:
+			// done scanning, as both may come at once
+			return
+		case <-time.After(500 * time.Millisecond):
+		}
+		if isatty.IsTerminal(in.Fd()) {
+			loginfo("Patiently waiting for stdin to send EOF (Ctrl+D). This is you! I'm reading from a TTY!")
+		} else {
+			// forcibly stop scanning if stuck on stdin
+			logdebug("forcibly closing stdin")
+			in.Close()
+		}
+	}()
+	src, err := humanlog.Decompress(in)
+	if err != nil {
+		return fmt.Errorf("reading stdin: %v", err)
+	}
+	defer src.Close()
+	return humanlog.Scan(ctx, src, snk, opts)
+}
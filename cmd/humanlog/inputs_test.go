@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandInputArgs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.log", "b.log", "c.json"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		wait    bool
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "stdin",
+			args: []string{"-"},
+			want: []string{"-"},
+		},
+		{
+			name: "plain file",
+			args: []string{filepath.Join(dir, "c.json")},
+			want: []string{filepath.Join(dir, "c.json")},
+		},
+		{
+			name: "glob and stdin",
+			args: []string{filepath.Join(dir, "*.log"), "-"},
+			want: []string{filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log"), "-"},
+		},
+		{
+			name:    "missing file",
+			args:    []string{filepath.Join(dir, "nope.log")},
+			wantErr: true,
+		},
+		{
+			name:    "glob matching nothing",
+			args:    []string{filepath.Join(dir, "*.txt")},
+			wantErr: true,
+		},
+		{
+			name: "missing file waited for",
+			args: []string{filepath.Join(dir, "nope.log")},
+			wait: true,
+			want: []string{filepath.Join(dir, "nope.log")},
+		},
+		{
+			name:    "glob matching nothing waited for",
+			args:    []string{filepath.Join(dir, "*.txt")},
+			wait:    true,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandInputArgs(tt.args, tt.wait)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
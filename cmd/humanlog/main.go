@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"log"
 	"log/slog"
@@ -11,9 +12,12 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"connectrpc.com/connect"
 	"github.com/99designs/keyring"
@@ -29,7 +33,6 @@ import (
 	"github.com/humanlogio/humanlog/pkg/sink/stdiosink"
 	"github.com/humanlogio/humanlog/pkg/sink/teesink"
 	"github.com/mattn/go-colorable"
-	"github.com/mattn/go-isatty"
 	"github.com/urfave/cli"
 	"golang.org/x/net/http2"
 	collogpb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
@@ -82,14 +85,20 @@ func fatalf(c *cli.Context, format string, args ...interface{}) {
 }
 
 func main() {
+	args, command := splitWrappedCommand(os.Args)
 	app := newApp()
+	app.Metadata = map[string]interface{}{wrappedCommandKey: command}
 
 	prefix := rgbterm.FgString(app.Name+"> ", 99, 99, 99)
 
 	log.SetOutput(colorable.NewColorableStderr())
 	log.SetFlags(0)
 	log.SetPrefix(prefix)
-	err := app.Run(os.Args)
+	err := app.Run(args)
+	var exitErr *childExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.code)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -178,6 +187,80 @@ func newApp() *cli.App {
 		Value:  &levelFields,
 	}
 
+	followFlag := cli.BoolFlag{
+		Name:  "follow, f",
+		Usage: "keep reading files after reaching their end, like tail -f",
+	}
+
+	followNameFlag := cli.BoolFlag{
+		Name:  "follow-name, F",
+		Usage: "like --follow, but also reopen files that are rotated, like tail -F",
+	}
+
+	mergeFlag := cli.BoolFlag{
+		Name:  "merge",
+		Usage: "interleave the events of all inputs by their timestamp, instead of by the order they're read in",
+	}
+
+	mergeWindowFlag := cli.DurationFlag{
+		Name:  "merge-window",
+		Usage: "with --merge, how long an event can be held back waiting for older events from other inputs",
+		Value: 2 * time.Second,
+	}
+
+	multilineFlag := cli.BoolFlag{
+		Name:   "multiline",
+		Usage:  "attach stack traces and other continuation lines to the structured log that precedes them",
+		EnvVar: "HUMANLOG_MULTILINE",
+	}
+
+	multilinePatterns := cli.StringSlice{}
+	multilinePatternsFlag := cli.StringSliceFlag{
+		Name:  "multiline-pattern",
+		Usage: "additional regexp matching continuation lines, implies --multiline",
+		Value: &multilinePatterns,
+	}
+
+	prefixPatterns := cli.StringSlice{}
+	prefixPatternsFlag := cli.StringSliceFlag{
+		Name:  "prefix-pattern",
+		Usage: "additional regexp matching a prefix put in front of structured lines; the rest must be matched by a (?P<rest_of_line>...) group, the other named groups become attributes",
+		Value: &prefixPatterns,
+	}
+
+	columnsFlag := cli.StringFlag{
+		Name:  "columns",
+		Usage: "comma separated names of the columns of CSV or TSV lines that have no header (e.g. time,level,logger,message)",
+	}
+
+	keepNestedFlag := cli.BoolFlag{
+		Name:   "keep-nested",
+		Usage:  "keep the objects and arrays of JSON lines nested when forwarding them, instead of flattening them into dotted keys",
+		EnvVar: "HUMANLOG_KEEP_NESTED",
+	}
+
+	handlersFlag := cli.StringFlag{
+		Name:  "handlers",
+		Usage: "comma separated handlers to try on lines, in order, among " + strings.Join(humanlog.RegisteredHandlers(), ","),
+	}
+
+	disabledHandlers := cli.StringSlice{}
+	disableHandlerFlag := cli.StringSliceFlag{
+		Name:  "disable-handler",
+		Usage: "handler never to try on lines, like zap-dev or container, or a single text format like text:glog",
+		Value: &disabledHandlers,
+	}
+
+	syslogUDPFlag := cli.StringFlag{
+		Name:  "syslog-udp",
+		Usage: "act as a syslog server, receiving messages over UDP on this address (e.g. localhost:5514)",
+	}
+
+	syslogTCPFlag := cli.StringFlag{
+		Name:  "syslog-tcp",
+		Usage: "act as a syslog server, receiving messages over TCP on this address (e.g. localhost:5514)",
+	}
+
 	apiServerURL := cli.StringFlag{
 		Name:   "api",
 		Value:  defaultApiURL,
@@ -221,7 +304,8 @@ func newApp() *cli.App {
 	app.Email = defaultBaseSiteURL + `/support`
 	app.Name = "humanlog"
 	app.Version = semverVersion.String()
-	app.Usage = "reads structured logs from stdin, makes them pretty on stdout!"
+	app.Usage = "reads structured logs from stdin or files, makes them pretty on stdout!"
+	app.ArgsUsage = "[file|glob|-]... | -- command [args]..."
 
 	var (
 		closers []func()
@@ -302,9 +386,126 @@ func newApp() *cli.App {
 		getConnectOpts = func(cctx *cli.Context) []connect.ClientOption {
 			return clOpts
 		}
+		// getHandlerOpts are the parser options of the config, with the
+		// parsing flags of the root command applied
+		getHandlerOpts = func(cctx *cli.Context) (*humanlog.HandlerOptions, error) {
+			parser := cfg.GetParser()
+			if parser == nil {
+				parser = &types.ParseConfig{}
+			}
+			handlerOpts := humanlog.HandlerOptionsFrom(parser)
+			if err := applyParserExtensions(handlerOpts, cfg.ParserExtensions); err != nil {
+				return nil, err
+			}
+			if (cctx.GlobalBool(multilineFlag.Name) || len(multilinePatterns) > 0) && handlerOpts.Multiline == nil {
+				handlerOpts.Multiline = humanlog.DefaultMultilineOptions()
+			}
+			for _, pattern := range multilinePatterns {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					return nil, fmt.Errorf("invalid --%s=%q: %v", multilinePatternsFlag.Name, pattern, err)
+				}
+				handlerOpts.Multiline.ContinuationPatterns = append(handlerOpts.Multiline.ContinuationPatterns, re)
+			}
+			if cctx.GlobalBool(keepNestedFlag.Name) {
+				handlerOpts.KeepNested = true
+			}
+			if columns := cctx.GlobalString(columnsFlag.Name); columns != "" {
+				handlerOpts.Columns = strings.Split(columns, ",")
+			}
+			if handlers := cctx.GlobalString(handlersFlag.Name); handlers != "" {
+				handlerOpts.Handlers = strings.Split(handlers, ",")
+				if err := checkHandlerNames(handlerOpts.Handlers, false); err != nil {
+					return nil, fmt.Errorf("invalid --%s: %v", handlersFlag.Name, err)
+				}
+			}
+			if err := checkHandlerNames(disabledHandlers, true); err != nil {
+				return nil, fmt.Errorf("invalid --%s: %v", disableHandlerFlag.Name, err)
+			}
+			handlerOpts.DisabledHandlers = append(handlerOpts.DisabledHandlers, disabledHandlers...)
+			for _, pattern := range prefixPatterns {
+				px, err := humanlog.NewPrefixExtractor(pattern, pattern)
+				if err != nil {
+					return nil, fmt.Errorf("invalid --%s=%q: %v", prefixPatternsFlag.Name, pattern, err)
+				}
+				handlerOpts.PrefixExtractors = append(handlerOpts.PrefixExtractors, px)
+			}
+			return handlerOpts, nil
+		}
+		// getSinks prints events to stdout, as the formatter of the config
+		// says, and `snk` also forwards them to the --otlp-endpoint if
+		// one is given. `flush` sends the events still to be forwarded.
+		getSinks = func(cctx *cli.Context) (stdio *stdiosink.Stdio, snk sink.Sink, flush func(), err error) {
+			sinkOpts, errs := stdiosink.StdioOptsFrom(cfg.Formatter)
+			for _, err := range errs {
+				logerror("config error: %v", err)
+			}
+			stdio, err = stdiosink.NewStdio(colorable.NewColorableStdout(), sinkOpts)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("preparing stdio printer: %v", err)
+			}
+			if !cctx.GlobalIsSet(otlpEndpoint.Name) {
+				return stdio, stdio, func() {}, nil
+			}
+			endpoint := cctx.GlobalString(otlpEndpoint.Name)
+			ll := getLogger(cctx)
+
+			var transportCreds grpc.DialOption
+			if u, err := url.Parse(endpoint); err == nil && u.Scheme == "http" {
+				transportCreds = grpc.WithTransportCredentials(insecure.NewCredentials())
+				// strip scheme for gRPC dial
+				endpoint = u.Host
+			} else {
+				transportCreds = grpc.WithTransportCredentials(credentials.NewTLS(nil))
+			}
+
+			userAgent := "humanlog OTLP exporter/" + semverVersion.String()
+			conn, err := grpc.NewClient(endpoint,
+				grpc.WithUserAgent(userAgent),
+				transportCreds,
+			)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("connecting to OTLP endpoint %q: %v", endpoint, err)
+			}
+			closers = append(closers, func() { _ = conn.Close() })
+
+			client := collogpb.NewLogsServiceClient(conn)
+			// events are sent with their own resource, this one is for
+			// those that have none
+			resource := types.NewResource("", nil)
+			scope := types.NewScope("", "humanlog", semverVersion.String(), nil)
+
+			flushTimeout := 300 * time.Millisecond
+			otlpctx, otlpcancel := context.WithCancel(context.WithoutCancel(ctx))
+			go func() {
+				<-ctx.Done()
+				time.Sleep(2 * flushTimeout)
+				otlpcancel()
+			}()
+
+			otlpSink := otlpsink.StartOTLPSink(otlpctx, ll, client, "otlp", resource, scope, 1_000, 100*time.Millisecond, false, func(err error) {
+				logerror("unable to send logs to OTLP endpoint: %v", err)
+			})
+			flush = func() {
+				fctx, fcancel := context.WithTimeout(context.Background(), flushTimeout)
+				defer fcancel()
+				ll.DebugContext(fctx, "flushing OTLP sink")
+				if err := otlpSink.Close(fctx); err != nil {
+					ll.ErrorContext(fctx, "couldn't flush OTLP sink", slog.Any("err", err))
+				}
+			}
+			loginfo("forwarding logs to OTLP endpoint %s", endpoint)
+			return stdio, teesink.NewTeeSink(stdio, otlpSink), flush, nil
+		}
 	)
 	app.Before = func(c *cli.Context) error {
-		ctx, cancel = signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+		if _, ok := wrappedCommand(c); ok {
+			// signals are forwarded to the wrapped command, which
+			// decides when we're done
+			ctx, cancel = context.WithCancel(context.Background())
+		} else {
+			ctx, cancel = signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+		}
 
 		// read config
 		dfltCfg, err := config.GetDefaultConfig(defaultReleaseChannel)
@@ -459,11 +660,28 @@ func newApp() *cli.App {
 		app.Commands,
 		versionCmd(getCtx, getLogger, getCfg, getState, getTokenSource, getAPIUrl, getBaseSiteURL, getHTTPClient, getConnectOpts),
 		configCmd(getCfg),
+		receiveCmd(getCtx, getLogger, getCfg, getSinks),
+		parseCmd(getHandlerOpts),
 	)
-	app.Flags = []cli.Flag{configFlag, skipFlag, keepFlag, sortLongest, skipUnchanged, truncates, truncateLength, colorFlag, timeFormat, ignoreInterrupts, messageFieldsFlag, timeFieldsFlag, levelFieldsFlag, otlpEndpoint, apiServerURL, baseSiteServerURL, debug, useHTTP1, useProtocol}
+	app.Flags = []cli.Flag{configFlag, skipFlag, keepFlag, sortLongest, skipUnchanged, truncates, truncateLength, colorFlag, timeFormat, ignoreInterrupts, messageFieldsFlag, timeFieldsFlag, levelFieldsFlag, followFlag, followNameFlag, mergeFlag, mergeWindowFlag, multilineFlag, multilinePatternsFlag, prefixPatternsFlag, columnsFlag, keepNestedFlag, handlersFlag, disableHandlerFlag, syslogUDPFlag, syslogTCPFlag, otlpEndpoint, apiServerURL, baseSiteServerURL, debug, useHTTP1, useProtocol}
 	app.Action = func(cctx *cli.Context) error {
+		command, wrapping := wrappedCommand(cctx)
+		if wrapping && len(cctx.Args()) > 0 {
+			return fmt.Errorf("can't read from files while running a command, got %q", []string(cctx.Args()))
+		}
+		syslogUDPAddr, syslogTCPAddr := cctx.String(syslogUDPFlag.Name), cctx.String(syslogTCPFlag.Name)
+		serveSyslog := syslogUDPAddr != "" || syslogTCPAddr != ""
+		if serveSyslog && (wrapping || len(cctx.Args()) > 0) {
+			return fmt.Errorf("can't read from files or run a command while acting as a syslog server")
+		}
+		inputs := []string{stdinInputName}
 		if len(cctx.Args()) > 0 {
-			return fmt.Errorf("unknown command: %s", strings.Join(cctx.Args(), " "))
+			// like `tail -F`, wait for the files that don't exist yet
+			expanded, err := expandInputArgs(cctx.Args(), cctx.Bool(strings.Split(followNameFlag.Name, ",")[0]))
+			if err != nil {
+				return err
+			}
+			inputs = expanded
 		}
 		// flags overwrite config file
 		if cfg.CurrentConfig == nil {
@@ -548,93 +766,47 @@ func newApp() *cli.App {
 			fatalf(cctx, "can only use one of %q and %q", skipFlag.Name, keepFlag.Name)
 		}
 
-		sinkOpts, errs := stdiosink.StdioOptsFrom(cfg.Formatter)
-		if len(errs) > 0 {
-			for _, err := range errs {
-				logerror("config error: %v", err)
-			}
+		_, snk, flush, err := getSinks(cctx)
+		if err != nil {
+			return err
 		}
-		var (
-			snk sink.Sink
-			err error
-		)
-		snk, err = stdiosink.NewStdio(colorable.NewColorableStdout(), sinkOpts)
+		defer flush()
+		handlerOpts, err := getHandlerOpts(cctx)
 		if err != nil {
-			return fmt.Errorf("preparing stdio printer: %v", err)
+			return err
+		}
+		for _, ep := range handlerOpts.ExternalParsers {
+			closers = append(closers, func() { _ = ep.Close() })
 		}
-		handlerOpts := humanlog.HandlerOptionsFrom(cfg.Parser)
-
-		// OTLP forwarding
-		if cctx.IsSet(otlpEndpoint.Name) {
-			endpoint := cctx.String(otlpEndpoint.Name)
-			ll := getLogger(cctx)
 
-			var transportCreds grpc.DialOption
-			if u, err := url.Parse(endpoint); err == nil && u.Scheme == "http" {
-				transportCreds = grpc.WithTransportCredentials(insecure.NewCredentials())
-				// strip scheme for gRPC dial
-				endpoint = u.Host
-			} else {
-				transportCreds = grpc.WithTransportCredentials(credentials.NewTLS(nil))
+		if wrapping {
+			return runWrapped(ctx, command, snk, handlerOpts)
+		}
+		if serveSyslog {
+			if syslogUDPAddr != "" {
+				loginfo("receiving syslog over UDP on %s", syslogUDPAddr)
 			}
-
-			userAgent := "humanlog OTLP exporter/" + semverVersion.String()
-			conn, err := grpc.NewClient(endpoint,
-				grpc.WithUserAgent(userAgent),
-				transportCreds,
-			)
-			if err != nil {
-				return fmt.Errorf("connecting to OTLP endpoint %q: %v", endpoint, err)
+			if syslogTCPAddr != "" {
+				loginfo("receiving syslog over TCP on %s", syslogTCPAddr)
 			}
-			closers = append(closers, func() { _ = conn.Close() })
-
-			client := collogpb.NewLogsServiceClient(conn)
-			resource := types.NewResource("", nil)
-			scope := types.NewScope("", "humanlog", semverVersion.String(), nil)
-
-			flushTimeout := 300 * time.Millisecond
-			otlpctx, otlpcancel := context.WithCancel(context.WithoutCancel(ctx))
-			go func() {
-				<-ctx.Done()
-				time.Sleep(2 * flushTimeout)
-				otlpcancel()
-			}()
-
-			otlpSink := otlpsink.StartOTLPSink(otlpctx, ll, client, "otlp", resource, scope, 1_000, 100*time.Millisecond, false, func(err error) {
-				logerror("unable to send logs to OTLP endpoint: %v", err)
-			})
-			defer func() {
-				fctx, fcancel := context.WithTimeout(context.Background(), flushTimeout)
-				defer fcancel()
-				ll.DebugContext(fctx, "flushing OTLP sink")
-				if err := otlpSink.Close(fctx); err != nil {
-					ll.ErrorContext(fctx, "couldn't flush OTLP sink", slog.Any("err", err))
-				}
-			}()
-			loginfo("forwarding logs to OTLP endpoint %s", endpoint)
-			snk = teesink.NewTeeSink(snk, otlpSink)
+			return humanlog.ServeSyslog(ctx, syslogUDPAddr, syslogTCPAddr, snk, handlerOpts)
 		}
 
-		in := os.Stdin
-		if isatty.IsTerminal(in.Fd()) {
-			loginfo("reading stdin...")
+		follow := noFollow
+		switch {
+		case cctx.Bool(strings.Split(followNameFlag.Name, ",")[0]):
+			follow = followName
+		case cctx.Bool(strings.Split(followFlag.Name, ",")[0]):
+			follow = followDescriptor
 		}
-		go func() {
-			<-ctx.Done()
-			logdebug("requested to stop scanning")
-			time.Sleep(500 * time.Millisecond)
-			if isatty.IsTerminal(in.Fd()) {
-				loginfo("Patiently waiting for stdin to send EOF (Ctrl+D). This is you! I'm reading from a TTY!")
-			} else {
-				// forcibly stop scanning if stuck on stdin
-				logdebug("forcibly closing stdin")
-				in.Close()
+		var mergeWindow time.Duration
+		if cctx.Bool(mergeFlag.Name) {
+			mergeWindow = cctx.Duration(mergeWindowFlag.Name)
+			if mergeWindow <= 0 {
+				return fmt.Errorf("--%s must be positive", mergeWindowFlag.Name)
 			}
-		}()
-
-		if err := humanlog.Scan(ctx, in, snk, handlerOpts); err != nil {
-			logerror("scanning caught an error: %v", err)
 		}
+		scanInputs(ctx, inputs, snk, handlerOpts, follow, mergeWindow)
 
 		return nil
 	}
@@ -652,3 +824,163 @@ func mustatoi(a string) int {
 	}
 	return i
 }
+
+// applyParserExtensions applies the parser extensions of the config file
+// to `handlerOpts`.
+func applyParserExtensions(handlerOpts *humanlog.HandlerOptions, pp *config.ParserExtensions) error {
+	if pp == nil {
+		return nil
+	}
+	for _, p := range pp.Patterns {
+		pattern, err := humanlog.CompilePattern(p.Name, p.Pattern, pp.Definitions)
+		if err != nil {
+			return fmt.Errorf("invalid parser pattern %q in config: %v", p.Name, err)
+		}
+		pattern.TimeLayout = p.TimeLayout
+		handlerOpts.Patterns = append(handlerOpts.Patterns, pattern)
+	}
+	for _, exp := range pp.Expand {
+		expansion, err := humanlog.NewFieldExpansion(exp.Field, exp.Formats)
+		if err != nil {
+			return fmt.Errorf("invalid parser expansion of %q in config: %v", exp.Field, err)
+		}
+		handlerOpts.Expand = append(handlerOpts.Expand, expansion)
+	}
+	seenLevels := make(map[string]string, len(pp.Severities))
+	for level, severity := range pp.Severities {
+		n, ok := humanlog.ParseSeverityNumber(severity)
+		if !ok {
+			return fmt.Errorf("invalid severity %q of level %q in config, must be a number from 1 to 24 or a name like INFO2", severity, level)
+		}
+		// levels are matched regardless of case
+		lower := strings.ToLower(level)
+		if other, ok := seenLevels[lower]; ok {
+			return fmt.Errorf("invalid severities in config, levels %q and %q only differ by case", other, level)
+		}
+		seenLevels[lower] = level
+		if handlerOpts.Severities == nil {
+			handlerOpts.Severities = make(map[string]uint32)
+		}
+		handlerOpts.Severities[lower] = n
+	}
+	if tc := pp.TraceContext; tc != nil {
+		handlerOpts.TraceIDFields = append(handlerOpts.TraceIDFields, tc.TraceIDFields...)
+		handlerOpts.SpanIDFields = append(handlerOpts.SpanIDFields, tc.SpanIDFields...)
+		handlerOpts.TraceFlagsFields = append(handlerOpts.TraceFlagsFields, tc.TraceFlagsFields...)
+		handlerOpts.TraceParentFields = append(handlerOpts.TraceParentFields, tc.TraceParentFields...)
+		handlerOpts.DecimalIDFields = append(handlerOpts.DecimalIDFields, tc.DecimalIDFields...)
+	}
+	var promotions []*humanlog.ResourcePromotion
+	for _, attr := range pp.ResourceAttributes {
+		if attr.Field == "" {
+			return fmt.Errorf("invalid parser resource attribute in config: no field")
+		}
+		promotions = append(promotions, &humanlog.ResourcePromotion{Field: attr.Field, As: attr.As})
+	}
+	if pp.PromoteResources != nil && *pp.PromoteResources {
+		promotions = append(promotions, humanlog.DefaultResourcePromotions()...)
+	}
+	// the promotions of the config come first, to override the usual ones
+	handlerOpts.ResourcePromotions = append(promotions, handlerOpts.ResourcePromotions...)
+	if pp.Handlers != nil {
+		if err := checkHandlerNames(pp.Handlers, false); err != nil {
+			return fmt.Errorf("invalid parser handlers in config: %v", err)
+		}
+		handlerOpts.Handlers = pp.Handlers
+	}
+	if err := checkHandlerNames(pp.DisabledHandlers, true); err != nil {
+		return fmt.Errorf("invalid parser disabled handlers in config: %v", err)
+	}
+	handlerOpts.DisabledHandlers = append(handlerOpts.DisabledHandlers, pp.DisabledHandlers...)
+	if len(pp.Prefixes) > 0 || len(pp.DisabledPrefixes) > 0 {
+		var prefixes []*humanlog.PrefixExtractor
+		for _, p := range pp.Prefixes {
+			px, err := humanlog.NewPrefixExtractor(p.Name, p.Pattern)
+			if err != nil {
+				return fmt.Errorf("invalid parser prefix %q in config: %v", p.Name, err)
+			}
+			px.Attributes = p.Attributes
+			px.ResourceAttributes = p.ResourceAttributes
+			prefixes = append(prefixes, px)
+		}
+		// the prefixes of the config come first, replacing the built-in
+		// ones of the same name
+		for _, px := range handlerOpts.PrefixExtractors {
+			replaced := slices.ContainsFunc(prefixes, func(p *humanlog.PrefixExtractor) bool { return p.Name == px.Name })
+			if !replaced && !slices.Contains(pp.DisabledPrefixes, px.Name) {
+				prefixes = append(prefixes, px)
+			}
+		}
+		handlerOpts.PrefixExtractors = prefixes
+	}
+	if ml := pp.Multiline; ml != nil {
+		handlerOpts.Multiline = humanlog.DefaultMultilineOptions()
+		for _, pattern := range ml.Patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid parser multiline pattern %q in config: %v", pattern, err)
+			}
+			handlerOpts.Multiline.ContinuationPatterns = append(handlerOpts.Multiline.ContinuationPatterns, re)
+		}
+		if ml.Key != "" {
+			handlerOpts.Multiline.Key = ml.Key
+		}
+		if ml.MaxLines > 0 {
+			handlerOpts.Multiline.MaxLines = ml.MaxLines
+		}
+		if ml.FlushAfter != "" {
+			d, err := time.ParseDuration(ml.FlushAfter)
+			if err != nil {
+				return fmt.Errorf("invalid parser multiline flush delay in config: %v", err)
+			}
+			handlerOpts.Multiline.FlushAfter = d
+		}
+	}
+	if pp.Columns != nil {
+		handlerOpts.Columns = pp.Columns
+	}
+	if pp.ColumnDelimiter != "" {
+		delim, size := utf8.DecodeRuneInString(pp.ColumnDelimiter)
+		if size != len(pp.ColumnDelimiter) || delim == utf8.RuneError || delim == '"' || delim == '\r' || delim == '\n' {
+			return fmt.Errorf("invalid parser column delimiter in config: %q", pp.ColumnDelimiter)
+		}
+		handlerOpts.ColumnDelimiter = delim
+	}
+	for _, ext := range pp.External {
+		ep, err := humanlog.NewExternalParser(ext.Name, ext.Command, ext.Encoding)
+		if err != nil {
+			return fmt.Errorf("invalid external parser %q in config: %v", ext.Name, err)
+		}
+		ep.Prefix = ext.Prefix
+		if ext.Match != "" {
+			if ep.Match, err = regexp.Compile(ext.Match); err != nil {
+				return fmt.Errorf("invalid match of external parser %q in config: %v", ext.Name, err)
+			}
+		}
+		if ext.Timeout != "" {
+			if ep.Timeout, err = time.ParseDuration(ext.Timeout); err != nil {
+				return fmt.Errorf("invalid timeout of external parser %q in config: %v", ext.Name, err)
+			}
+		}
+		ep.OnError = func(err error) { logerror("%v", err) }
+		handlerOpts.ExternalParsers = append(handlerOpts.ExternalParsers, ep)
+	}
+	return nil
+}
+
+// checkHandlerNames checks that `names` are registered handlers. Single
+// patterns, text formats and external parsers are allowed when `single`
+// is set.
+func checkHandlerNames(names []string, single bool) error {
+	registered := humanlog.RegisteredHandlers()
+	for _, name := range names {
+		if slices.Contains(registered, name) {
+			continue
+		}
+		if single && (strings.HasPrefix(name, "pattern:") || strings.HasPrefix(name, "text:") || strings.HasPrefix(name, "external:")) {
+			continue
+		}
+		return fmt.Errorf("unknown handler %q, must be one of %v", name, registered)
+	}
+	return nil
+}
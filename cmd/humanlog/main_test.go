@@ -3,9 +3,13 @@ package main
 import (
 	"encoding/json"
 	"os"
+	"regexp"
+	"slices"
 	"testing"
+	"time"
 
 	typesv1 "github.com/minitape/api/go/types/v1"
+	"github.com/humanlogio/humanlog"
 	"github.com/humanlogio/humanlog/internal/pkg/config"
 	"github.com/stretchr/testify/require"
 )
@@ -39,3 +43,71 @@ func TestApplyConfigFromConfigFile_when_one_of_skip_or_keep_is_given(t *testing.
 		t.Fatal(err)
 	}
 }
+
+func TestApplyParserExtensionsMultiline(t *testing.T) {
+	handlerOpts := humanlog.DefaultOptions()
+	err := applyParserExtensions(handlerOpts, &config.ParserExtensions{
+		Multiline: &config.ParseMultiline{Patterns: []string{`^\s*\|`}, FlushAfter: "1s"},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, handlerOpts.Multiline)
+	require.Equal(t, time.Second, handlerOpts.Multiline.FlushAfter)
+	require.True(t, slices.ContainsFunc(handlerOpts.Multiline.ContinuationPatterns, func(re *regexp.Regexp) bool {
+		return re.MatchString("  | continued")
+	}))
+
+	err = applyParserExtensions(humanlog.DefaultOptions(), &config.ParserExtensions{
+		Multiline: &config.ParseMultiline{Patterns: []string{`(`}},
+	})
+	require.Error(t, err)
+}
+
+func TestApplyParserExtensionsResourcePromotions(t *testing.T) {
+	handlerOpts := humanlog.DefaultOptions()
+	require.Empty(t, handlerOpts.ResourcePromotions)
+	promote := true
+	err := applyParserExtensions(handlerOpts, &config.ParserExtensions{
+		ResourceAttributes: []*config.ParseResourceAttribute{{Field: "env", As: humanlog.KeepAttribute}},
+		PromoteResources:   &promote,
+	})
+	require.NoError(t, err)
+	require.Equal(t, &humanlog.ResourcePromotion{Field: "env", As: humanlog.KeepAttribute}, handlerOpts.ResourcePromotions[0])
+	require.Equal(t, humanlog.DefaultResourcePromotions(), handlerOpts.ResourcePromotions[1:])
+}
+
+func TestApplyParserExtensionsColumns(t *testing.T) {
+	handlerOpts := humanlog.DefaultOptions()
+	err := applyParserExtensions(handlerOpts, &config.ParserExtensions{
+		Columns:         []string{"time", "level", "message"},
+		ColumnDelimiter: "|",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"time", "level", "message"}, handlerOpts.Columns)
+	require.Equal(t, '|', handlerOpts.ColumnDelimiter)
+
+	err = applyParserExtensions(humanlog.DefaultOptions(), &config.ParserExtensions{ColumnDelimiter: "||"})
+	require.Error(t, err)
+}
+
+func TestApplyParserExtensionsPrefixes(t *testing.T) {
+	handlerOpts := humanlog.DefaultOptions()
+	err := applyParserExtensions(handlerOpts, &config.ParserExtensions{
+		Prefixes: []*config.ParsePrefix{
+			{Name: "mine", Pattern: `^mine: (?P<rest_of_line>.*)$`},
+			{Name: "stern", Pattern: `^(?P<pod>\S+) (?P<rest_of_line>.*)$`},
+		},
+		DisabledPrefixes: []string{"kubectl"},
+	})
+	require.NoError(t, err)
+	var names []string
+	for _, px := range handlerOpts.PrefixExtractors {
+		names = append(names, px.Name)
+	}
+	require.Equal(t, []string{"mine", "stern", "docker-compose"}, names)
+	require.Equal(t, `^(?P<pod>\S+) (?P<rest_of_line>.*)$`, handlerOpts.PrefixExtractors[1].Pattern.String())
+
+	err = applyParserExtensions(humanlog.DefaultOptions(), &config.ParserExtensions{
+		Prefixes: []*config.ParsePrefix{{Name: "mine", Pattern: `^mine: `}},
+	})
+	require.Error(t, err)
+}
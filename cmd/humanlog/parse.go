@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/humanlogio/humanlog"
+	types "github.com/minitape/api/go/types/v1"
+	"github.com/urfave/cli"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	parseCmdName = "parse"
+)
+
+func parseCmd(
+	getHandlerOpts func(cctx *cli.Context) (*humanlog.HandlerOptions, error),
+) cli.Command {
+	return cli.Command{
+		Name:      parseCmdName,
+		Usage:     "Explain how sample lines are parsed: which handler recognized them, which field lists were looked into, and the resulting event.",
+		ArgsUsage: "[file|glob|-]...",
+		Action: func(cctx *cli.Context) error {
+			handlerOpts, err := getHandlerOpts(cctx)
+			if err != nil {
+				return err
+			}
+			for _, ep := range handlerOpts.ExternalParsers {
+				defer ep.Close()
+			}
+			inputs := []string{stdinInputName}
+			if len(cctx.Args()) > 0 {
+				expanded, err := expandInputArgs(cctx.Args(), false)
+				if err != nil {
+					return err
+				}
+				inputs = expanded
+			}
+
+			out := bufio.NewWriter(os.Stdout)
+			defer out.Flush()
+			explainer := humanlog.NewExplainer(handlerOpts)
+			for _, input := range inputs {
+				if input == stdinInputName {
+					src, err := humanlog.Decompress(os.Stdin)
+					if err != nil {
+						return fmt.Errorf("reading stdin: %v", err)
+					}
+					err = explainLines(out, src, explainer)
+					_ = src.Close()
+					if err != nil {
+						return err
+					}
+					continue
+				}
+				f, err := os.Open(input)
+				if err != nil {
+					return err
+				}
+				src, err := humanlog.Decompress(f)
+				if err == nil {
+					err = explainLines(out, src, explainer)
+					_ = src.Close()
+				}
+				_ = f.Close()
+				if err != nil {
+					return fmt.Errorf("reading %q: %v", input, err)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// explainLines writes how each line of `r` is parsed to `w`.
+func explainLines(w io.Writer, r io.Reader, explainer *humanlog.Explainer) error {
+	in := bufio.NewScanner(r)
+	in.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	marshaler := protojson.MarshalOptions{Multiline: true, Indent: "  "}
+	for n := 1; in.Scan(); n++ {
+		ex := explainer.Explain(in.Bytes())
+
+		fmt.Fprintf(w, "line %d: %s\n", n, ex.Line)
+		handler := ex.Handler
+		if handler == "" {
+			handler = "none, the line isn't structured"
+		}
+		fmt.Fprintf(w, "handler: %s\n", handler)
+		if len(ex.Rejected) > 0 {
+			fmt.Fprintf(w, "rejected by: %s\n", strings.Join(ex.Rejected, ", "))
+		}
+		for _, lookup := range ex.Lookups {
+			if lookup.Found != "" {
+				fmt.Fprintf(w, "%s: found %q in [%s]\n", lookup.Option, lookup.Found, strings.Join(lookup.Fields, " "))
+			} else {
+				fmt.Fprintf(w, "%s: none of [%s]\n", lookup.Option, strings.Join(lookup.Fields, " "))
+			}
+		}
+		switch {
+		case ex.Log == nil:
+			fmt.Fprintln(w, "event: none, the line is part of one")
+		case ex.Log.IsStructured():
+			ev := proto.Clone(ex.Log).(*types.Log)
+			// already shown, or not learned from the line
+			ev.Raw, ev.Ulid, ev.ObservedTimestamp = nil, nil, nil
+			d, err := marshaler.Marshal(ev)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "event: %s\n", d)
+		}
+		fmt.Fprintln(w)
+	}
+	return in.Err()
+}
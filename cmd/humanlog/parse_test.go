@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/humanlogio/humanlog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainLines(t *testing.T) {
+	opts := humanlog.DefaultOptions()
+	opts.TextFormats = nil
+	opts.TimeFields = []string{"ts"}
+	opts.MessageFields = []string{"msg"}
+	opts.LevelFields = []string{"level"}
+
+	in := strings.NewReader(`{"level":"info","msg":"hello"}` + "\n" + "plain text\n")
+	out := bytes.NewBuffer(nil)
+	require.NoError(t, explainLines(out, in, humanlog.NewExplainer(opts)))
+
+	want := `line 1: {"level":"info","msg":"hello"}
+handler: json
+rejected by: container, table
+TimeFields: none of [ts]
+LevelFields: found "level" in [level]
+MessageFields: found "msg" in [msg]
+event: {
+  "severityText": "info",
+  "severityNumber": 9,
+  "body": "hello"
+}
+
+line 2: plain text
+handler: none, the line isn't structured
+rejected by: container, table, json, syslog, logfmt, prefix+json, prefix+logfmt, zap-dev
+
+`
+	// protojson randomly adds spaces, so that its output isn't relied on
+	got := strings.ReplaceAll(out.String(), ":  ", ": ")
+	require.Equal(t, want, got)
+}
+
+func TestParseCmdRootFlags(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(cfgPath, []byte(`{"version":2}`), 0600))
+
+	logPath := filepath.Join(dir, "app.log.gz")
+	buf := bytes.NewBuffer(nil)
+	zw := gzip.NewWriter(buf)
+	_, err := zw.Write([]byte("level=info msg=hello\n"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.NoError(t, os.WriteFile(logPath, buf.Bytes(), 0600))
+
+	outPath := filepath.Join(dir, "out")
+	out, err := os.Create(outPath)
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = out
+	t.Cleanup(func() { os.Stdout = stdout })
+
+	args := []string{"program-path", "--config", cfgPath, "--handlers", "logfmt", "parse", logPath}
+	require.NoError(t, newApp().Run(args))
+	require.NoError(t, out.Close())
+
+	got, err := os.ReadFile(outPath)
+	require.NoError(t, err)
+	// only the handler picked by the flag is tried, on the decompressed line
+	require.Contains(t, string(got), "line 1: level=info msg=hello\nhandler: logfmt\n")
+	require.NotContains(t, string(got), "rejected by")
+}
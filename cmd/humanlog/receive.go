@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"strconv"
+
+	"github.com/humanlogio/humanlog/internal/pkg/config"
+	"github.com/humanlogio/humanlog/internal/pkg/otlpreceiver"
+	"github.com/humanlogio/humanlog/pkg/sink"
+	"github.com/humanlogio/humanlog/pkg/sink/stdiosink"
+	"github.com/urfave/cli"
+)
+
+const (
+	receiveCmdName = "receive"
+)
+
+func receiveCmd(
+	getCtx func(cctx *cli.Context) context.Context,
+	getLogger func(cctx *cli.Context) *slog.Logger,
+	getCfg func(cctx *cli.Context) *config.Config,
+	getSinks func(cctx *cli.Context) (*stdiosink.Stdio, sink.Sink, func(), error),
+) cli.Command {
+	bindFlag := cli.StringFlag{
+		Name:  "bind",
+		Usage: "address to listen on, defaults to the server's configured bind address or localhost",
+	}
+	grpcPortFlag := cli.IntFlag{
+		Name:  "grpc-port",
+		Usage: "port for OTLP/gRPC, defaults to the server's configured one or " + strconv.Itoa(otlpreceiver.DefaultGRPCPort),
+	}
+	httpPortFlag := cli.IntFlag{
+		Name:  "http-port",
+		Usage: "port for OTLP/HTTP, defaults to the server's configured one or " + strconv.Itoa(otlpreceiver.DefaultHTTPPort),
+	}
+	return cli.Command{
+		Name:  receiveCmdName,
+		Usage: "Receive logs and traces from OpenTelemetry exporters and make them pretty on stdout.",
+		Flags: []cli.Flag{bindFlag, grpcPortFlag, httpPortFlag},
+		Action: func(cctx *cli.Context) error {
+			ctx := getCtx(cctx)
+			cfg := getCfg(cctx)
+
+			srvCfg := cfg.GetRuntime().GetServer()
+			host := srvCfg.GetBindAddress()
+			if cctx.IsSet(bindFlag.Name) {
+				host = cctx.String(bindFlag.Name)
+			}
+			if host == "" {
+				host = "localhost"
+			}
+			grpcPort := int(srvCfg.GetOtlp().GetGrpcPort())
+			if cctx.IsSet(grpcPortFlag.Name) {
+				grpcPort = cctx.Int(grpcPortFlag.Name)
+			}
+			if grpcPort == 0 {
+				grpcPort = otlpreceiver.DefaultGRPCPort
+			}
+			httpPort := int(srvCfg.GetOtlp().GetHttpPort())
+			if cctx.IsSet(httpPortFlag.Name) {
+				httpPort = cctx.Int(httpPortFlag.Name)
+			}
+			if httpPort == 0 {
+				httpPort = otlpreceiver.DefaultHTTPPort
+			}
+
+			stdio, snk, flush, err := getSinks(cctx)
+			if err != nil {
+				return err
+			}
+			defer flush()
+
+			grpcAddr := net.JoinHostPort(host, strconv.Itoa(grpcPort))
+			httpAddr := net.JoinHostPort(host, strconv.Itoa(httpPort))
+			loginfo("receiving OTLP on %s (gRPC) and http://%s (HTTP)", grpcAddr, httpAddr)
+
+			// spans are only printed
+			rcv := otlpreceiver.NewReceiver(getLogger(cctx), snk, stdio)
+			return rcv.Serve(ctx, grpcAddr, httpAddr)
+		},
+	}
+}
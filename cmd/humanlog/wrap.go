@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/humanlogio/humanlog"
+	"github.com/humanlogio/humanlog/pkg/sink"
+	"github.com/humanlogio/humanlog/pkg/sink/attrsink"
+	"github.com/humanlogio/humanlog/pkg/sink/syncsink"
+	types "github.com/minitape/api/go/types/v1"
+	"github.com/urfave/cli"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+)
+
+// wrappedCommandKey is where the command given after `--` is kept in the
+// app's metadata.
+const wrappedCommandKey = "wrapped-command"
+
+// splitWrappedCommand separates humanlog's own arguments from the command
+// it's asked to run, which follows a `--`. The command is nil if there's
+// no `--`.
+func splitWrappedCommand(args []string) (own, command []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i], append([]string{}, args[i+1:]...)
+		}
+	}
+	return args, nil
+}
+
+func wrappedCommand(cctx *cli.Context) ([]string, bool) {
+	command, ok := cctx.App.Metadata[wrappedCommandKey].([]string)
+	return command, ok && command != nil
+}
+
+// childExitError carries the exit code of the wrapped command, for
+// humanlog to exit with it.
+type childExitError struct {
+	code int
+}
+
+func (err *childExitError) Error() string {
+	return fmt.Sprintf("command exited with code %d", err.code)
+}
+
+// runWrapped runs `command`, scanning its stdout and stderr into `snk`
+// until they are closed. Events are tagged with the stream they were
+// read from.
+func runWrapped(ctx context.Context, command []string, snk sink.Sink, opts *humanlog.HandlerOptions) error {
+	if len(command) == 0 {
+		return fmt.Errorf("missing command to run after `--`")
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = os.Stdin
+	detachProcessGroup(cmd)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, forwardedSignals...)
+	defer signal.Stop(sigc)
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting %q: %v", command[0], err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case sig := <-sigc:
+				logdebug("forwarding %v to %q", sig, command[0])
+				if err := cmd.Process.Signal(sig); err != nil {
+					logdebug("can't forward %v: %v", sig, err)
+				}
+			}
+		}
+	}()
+
+	// the command decides when it's done, so keep reading what it
+	// writes while it shuts down
+	scanCtx := context.WithoutCancel(ctx)
+	snk = syncsink.NewSyncSink(snk)
+	var wg sync.WaitGroup
+	scanStream := func(r io.Reader, stream string) {
+		defer wg.Done()
+		attr := types.KeyVal(string(semconv.LogIostreamKey), types.ValStr(stream))
+		if err := humanlog.Scan(scanCtx, r, attrsink.NewAttrSink(snk, attr), opts); err != nil {
+			logerror("scanning %s caught an error: %v", stream, err)
+		}
+	}
+	wg.Add(2)
+	go scanStream(stdout, "stdout")
+	go scanStream(stderr, "stderr")
+	wg.Wait()
+
+	return exitStatus(cmd.Wait())
+}
+
+// exitStatus turns the error of a finished command into the exit code to
+// pass on, following the shell convention of 128+n for commands killed
+// by signal n.
+func exitStatus(err error) error {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+	code := exitErr.ExitCode()
+	if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		code = 128 + int(ws.Signal())
+	}
+	return &childExitError{code: code}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/humanlogio/humanlog"
+	"github.com/humanlogio/humanlog/pkg/sink/bufsink"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitWrappedCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantOwn     []string
+		wantCommand []string
+	}{
+		{
+			name:    "no command",
+			args:    []string{"humanlog", "--skip", "foo", "app.log"},
+			wantOwn: []string{"humanlog", "--skip", "foo", "app.log"},
+		},
+		{
+			name:        "command",
+			args:        []string{"humanlog", "--skip", "foo", "--", "kubectl", "logs", "-f", "--", "pod"},
+			wantOwn:     []string{"humanlog", "--skip", "foo"},
+			wantCommand: []string{"kubectl", "logs", "-f", "--", "pod"},
+		},
+		{
+			name:        "empty command",
+			args:        []string{"humanlog", "--"},
+			wantOwn:     []string{"humanlog"},
+			wantCommand: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			own, command := splitWrappedCommand(tt.args)
+			require.Equal(t, tt.wantOwn, own)
+			require.Equal(t, tt.wantCommand, command)
+		})
+	}
+}
+
+func TestRunWrapped(t *testing.T) {
+	ctx := context.Background()
+	snk := bufsink.NewSizedBufferedSink(100, nil)
+	command := []string{"sh", "-c", `echo '{"msg":"to stdout"}'; echo 'msg="to stderr"' >&2; echo 'plain text' >&2; exit 3`}
+
+	err := runWrapped(ctx, command, snk, humanlog.DefaultOptions())
+	var exitErr *childExitError
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, 3, exitErr.code)
+
+	var got []string
+	for _, ev := range snk.Buffered {
+		for _, kv := range ev.Resource.GetAttributes() {
+			if kv.Key == "log.iostream" {
+				body := ev.Body
+				if !ev.IsStructured() {
+					body = string(ev.Raw)
+				}
+				got = append(got, body+"="+kv.Value.GetStr())
+			}
+		}
+	}
+	sort.Strings(got)
+	require.Equal(t, []string{"plain text=stderr", "to stderr=stderr", "to stdout=stdout"}, got)
+}
+
+func TestRunWrappedKilled(t *testing.T) {
+	ctx := context.Background()
+	snk := bufsink.NewSizedBufferedSink(100, nil)
+
+	err := runWrapped(ctx, []string{"sh", "-c", "kill -TERM $$"}, snk, humanlog.DefaultOptions())
+	var exitErr *childExitError
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, 128+15, exitErr.code)
+}
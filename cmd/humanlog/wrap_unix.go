@@ -0,0 +1,34 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/mattn/go-isatty"
+)
+
+// forwardedSignals are relayed to the wrapped command instead of stopping
+// humanlog, which instead stops once the command is done.
+var forwardedSignals = []os.Signal{
+	os.Interrupt,
+	syscall.SIGTERM,
+	syscall.SIGHUP,
+	syscall.SIGQUIT,
+	syscall.SIGUSR1,
+	syscall.SIGUSR2,
+}
+
+// detachProcessGroup starts the command in a process group of its own, so
+// that the signals the terminal sends to humanlog's group, like on Ctrl+C,
+// reach it only once, when humanlog forwards them. Out of the terminal's
+// foreground, the command would be stopped as soon as it reads from the
+// terminal, so it's given no input then.
+func detachProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if cmd.Stdin == os.Stdin && isatty.IsTerminal(os.Stdin.Fd()) {
+		cmd.Stdin = nil
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+)
+
+// forwardedSignals are relayed to the wrapped command instead of stopping
+// humanlog, which instead stops once the command is done. Windows can't
+// relay an interrupt, but the console sends it to the command already.
+var forwardedSignals = []os.Signal{
+	os.Interrupt,
+}
+
+// detachProcessGroup does nothing, as the console interrupts every
+// process attached to it.
+func detachProcessGroup(cmd *exec.Cmd) {}
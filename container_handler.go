@@ -0,0 +1,97 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"time"
+
+	typesv1 "github.com/minitape/api/go/types/v1"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Docker's `json-file` driver wraps each line written by a container in
+// a JSON envelope, like:
+//
+//	{"log":"hello world\n","stream":"stdout","time":"2024-01-01T00:00:00.000000001Z"}
+//
+// Lines longer than 16KiB are split over many envelopes, only the last of
+// which ends with a newline.
+var dockerJSONFilePrefix = []byte(`{"log":`)
+
+type dockerJSONFileLine struct {
+	Log    *string `json:"log"`
+	Stream string  `json:"stream"`
+	Time   string  `json:"time"`
+}
+
+// CRI runtimes (containerd, CRI-O) write container logs as lines made of
+// the following, separated by a space:
+//  1. the time in RFC 3339, with nanoseconds
+//  2. the stream, stdout or stderr
+//  3. a tag, `P` for a partial line, or `F` for the full (or last part of
+//     a) line
+//  4. the line itself
+var criLineRe = regexp.MustCompile(`^(?P<time>\d{4}-\d{2}-\d{2}T\S+) (?P<stream>stdout|stderr) (?P<tag>[PF])(?: (?P<log>.*))?$`)
+
+// containerLogHandler unwraps the lines of container log files, and
+// parses the lines within them with its own handlers.
+type containerLogHandler struct {
+	handle func([]byte, *typesv1.Log) (handled, complete bool)
+
+	// partial accumulates the parts of the lines split over many, by
+	// stream, as the lines of both streams are interleaved
+	partial map[string][]byte
+}
+
+func (h *containerLogHandler) TryHandle(d []byte, ev *typesv1.Log) (handled, complete bool) {
+	if bytes.HasPrefix(d, dockerJSONFilePrefix) {
+		var line dockerJSONFileLine
+		if err := json.Unmarshal(d, &line); err != nil || line.Log == nil || line.Stream == "" {
+			return false, false
+		}
+		content, ended := bytes.CutSuffix([]byte(*line.Log), []byte("\n"))
+		return true, h.unwrap(ev, line.Time, line.Stream, content, !ended)
+	}
+	if len(d) > 0 && d[0] >= '0' && d[0] <= '9' {
+		matches := criLineRe.FindSubmatch(d)
+		if matches == nil {
+			return false, false
+		}
+		return true, h.unwrap(ev, string(matches[1]), string(matches[2]), matches[4], string(matches[3]) == "P")
+	}
+	return false, false
+}
+
+// unwrap parses the line within a container log envelope into `ev`. If
+// it's only part of a line, it's kept until the rest comes and `ev` is
+// left as is.
+func (h *containerLogHandler) unwrap(ev *typesv1.Log, ts, stream string, content []byte, isPartial bool) (complete bool) {
+	if partial := h.partial[stream]; len(partial) > 0 {
+		content = append(partial, content...)
+		delete(h.partial, stream)
+	} else {
+		content = bytes.Clone(content)
+	}
+	// lines that never end are cut where they'd outgrow the buffer
+	if isPartial && len(content) < maxBufferSize {
+		if h.partial == nil {
+			h.partial = make(map[string][]byte)
+		}
+		h.partial[stream] = content
+		return false
+	}
+
+	ev.Raw = content
+	if handled, _ := h.handle(content, ev); !handled {
+		ev.Body = string(content)
+	}
+	if ev.Timestamp == nil {
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			ev.Timestamp = timestamppb.New(t)
+		}
+	}
+	ev.Attributes = append(ev.Attributes, typesv1.KeyVal(string(semconv.LogIostreamKey), typesv1.ValStr(stream)))
+	return true
+}
@@ -0,0 +1,172 @@
+package humanlog
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"github.com/humanlogio/humanlog/pkg/sink/bufsink"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestContainerLogs(t *testing.T) {
+	now := time.Date(2024, 10, 11, 15, 25, 6, 0, time.UTC)
+	envelopeTime := time.Date(2024, 1, 1, 0, 0, 0, 100000000, time.UTC)
+	appTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		input string
+		want  []*typesv1.Log
+	}{
+		{
+			name: "docker json-file",
+			input: `{"log":"{\"level\":\"info\",\"msg\":\"hello\",\"ts\":\"2024-01-01T00:00:00Z\"}\n","stream":"stdout","time":"2024-01-01T00:00:00.1Z"}
+{"log":"plain text\n","stream":"stderr","time":"2024-01-01T00:00:00.1Z"}`,
+			want: []*typesv1.Log{
+				{
+					Raw:            []byte(`{"level":"info","msg":"hello","ts":"2024-01-01T00:00:00Z"}`),
+					Timestamp:      timestamppb.New(appTime),
+					SeverityText:   "info",
+					SeverityNumber: 9,
+					Body:           "hello",
+					Attributes: []*typesv1.KV{
+						typesv1.KeyVal("log.iostream", typesv1.ValStr("stdout")),
+					},
+				},
+				{
+					Raw:       []byte(`plain text`),
+					Timestamp: timestamppb.New(envelopeTime),
+					Body:      "plain text",
+					Attributes: []*typesv1.KV{
+						typesv1.KeyVal("log.iostream", typesv1.ValStr("stderr")),
+					},
+				},
+			},
+		},
+		{
+			name: "docker json-file split line",
+			input: `{"log":"{\"msg\":\"long","stream":"stdout","time":"2024-01-01T00:00:00.1Z"}
+{"log":" line\"}\n","stream":"stdout","time":"2024-01-01T00:00:00.1Z"}`,
+			want: []*typesv1.Log{
+				{
+					Raw:       []byte(`{"msg":"long line"}`),
+					Timestamp: timestamppb.New(envelopeTime),
+					Body:      "long line",
+					Attributes: []*typesv1.KV{
+						typesv1.KeyVal("log.iostream", typesv1.ValStr("stdout")),
+					},
+				},
+			},
+		},
+		{
+			name: "cri with partial lines",
+			input: `2024-01-01T00:00:00.1Z stdout P level=warn msg="split
+2024-01-01T00:00:00.1Z stdout P  in
+2024-01-01T00:00:00.1Z stdout F  three" k=v
+2024-01-01T00:00:00.1Z stderr F oops`,
+			want: []*typesv1.Log{
+				{
+					Raw:            []byte(`level=warn msg="split in three" k=v`),
+					Timestamp:      timestamppb.New(envelopeTime),
+					SeverityText:   "warn",
+					SeverityNumber: 13,
+					Body:           "split in three",
+					Attributes: []*typesv1.KV{
+						typesv1.KeyVal("k", typesv1.ValStr("v")),
+						typesv1.KeyVal("log.iostream", typesv1.ValStr("stdout")),
+					},
+				},
+				{
+					Raw:       []byte(`oops`),
+					Timestamp: timestamppb.New(envelopeTime),
+					Body:      "oops",
+					Attributes: []*typesv1.KV{
+						typesv1.KeyVal("log.iostream", typesv1.ValStr("stderr")),
+					},
+				},
+			},
+		},
+		{
+			name: "cri with interleaved partial lines",
+			input: `2024-01-01T00:00:00.1Z stdout P out
+2024-01-01T00:00:00.1Z stderr P err
+2024-01-01T00:00:00.1Z stdout F put
+2024-01-01T00:00:00.1Z stderr F or`,
+			want: []*typesv1.Log{
+				{
+					Raw:       []byte(`output`),
+					Timestamp: timestamppb.New(envelopeTime),
+					Body:      "output",
+					Attributes: []*typesv1.KV{
+						typesv1.KeyVal("log.iostream", typesv1.ValStr("stdout")),
+					},
+				},
+				{
+					Raw:       []byte(`error`),
+					Timestamp: timestamppb.New(envelopeTime),
+					Body:      "error",
+					Attributes: []*typesv1.KV{
+						typesv1.KeyVal("log.iostream", typesv1.ValStr("stderr")),
+					},
+				},
+			},
+		},
+		{
+			name: "cri with a line that never ends",
+			input: strings.Repeat("2024-01-01T00:00:00.1Z stdout P "+strings.Repeat("a", maxBufferSize/2)+"\n", 2) +
+				"2024-01-01T00:00:00.1Z stdout F end",
+			want: []*typesv1.Log{
+				{
+					Raw:       []byte(strings.Repeat("a", maxBufferSize)),
+					Timestamp: timestamppb.New(envelopeTime),
+					Body:      strings.Repeat("a", maxBufferSize),
+					Attributes: []*typesv1.KV{
+						typesv1.KeyVal("log.iostream", typesv1.ValStr("stdout")),
+					},
+				},
+				{
+					Raw:       []byte(`end`),
+					Timestamp: timestamppb.New(envelopeTime),
+					Body:      "end",
+					Attributes: []*typesv1.KV{
+						typesv1.KeyVal("log.iostream", typesv1.ValStr("stdout")),
+					},
+				},
+			},
+		},
+		{
+			name:  "json with a log field isn't an envelope",
+			input: `{"log":"hello"}`,
+			want: []*typesv1.Log{
+				{
+					Raw: []byte(`{"log":"hello"}`),
+					Attributes: []*typesv1.KV{
+						typesv1.KeyVal("log", typesv1.ValStr("hello")),
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.newULID = func() *typesv1.ULID { return nil }
+			opts.timeNow = func() time.Time { return now }
+
+			sink := bufsink.NewSizedBufferedSink(100, nil)
+			require.NoError(t, Scan(context.Background(), strings.NewReader(tt.input), sink, opts))
+
+			require.Len(t, sink.Buffered, len(tt.want))
+			for i, want := range tt.want {
+				want.ObservedTimestamp = timestamppb.New(now)
+				diff := cmp.Diff(want, sink.Buffered[i], protocmp.Transform())
+				require.Empty(t, diff, "log %d", i)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package humanlog
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// sniffLen is how many bytes are needed to recognize any of the
+// supported compression formats.
+const sniffLen = 4
+
+type compression struct {
+	name       string
+	magic      []byte
+	decompress func(io.Reader) (io.ReadCloser, error)
+}
+
+var compressions = []compression{
+	{
+		name:  "gzip",
+		magic: []byte{0x1f, 0x8b},
+		decompress: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+	},
+	{
+		name:  "zstd",
+		magic: []byte{0x28, 0xb5, 0x2f, 0xfd},
+		decompress: func(r io.Reader) (io.ReadCloser, error) {
+			zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+			if err != nil {
+				return nil, err
+			}
+			return zr.IOReadCloser(), nil
+		},
+	},
+	{
+		name:  "bzip2",
+		magic: []byte{'B', 'Z', 'h'},
+		decompress: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(bzip2.NewReader(r)), nil
+		},
+	},
+}
+
+// sniff tells if `header` is the start of a stream of this format, or if
+// more bytes are needed to tell.
+func (c *compression) sniff(header []byte) (match, needMore bool) {
+	n := min(len(header), len(c.magic))
+	if !bytes.Equal(header[:n], c.magic[:n]) {
+		return false, false
+	}
+	if c.name == "bzip2" {
+		// "BZh" is plausible text, also check that the 4th byte is a
+		// valid block size ('1' to '9')
+		if len(header) < sniffLen {
+			return false, true
+		}
+		return header[3] >= '1' && header[3] <= '9', false
+	}
+	if len(header) < len(c.magic) {
+		return false, true
+	}
+	return true, false
+}
+
+// Decompress sniffs the first bytes of `r` and, if they are the magic
+// number of a gzip, zstd or bzip2 stream, returns a reader that
+// decompresses `r` on the fly. Otherwise the returned reader yields
+// the content of `r` unchanged. Closing the returned reader releases
+// what the decompression holds, but leaves `r` open.
+//
+// Sniffing only waits for more bytes while what was read so far could
+// still be a magic number, so it doesn't get stuck on short lines typed
+// in a terminal.
+func Decompress(r io.Reader) (io.ReadCloser, error) {
+	header := make([]byte, 0, sniffLen)
+	for {
+		n, err := r.Read(header[len(header):sniffLen])
+		header = header[:len(header)+n]
+
+		needMore := false
+		for _, c := range compressions {
+			match, more := c.sniff(header)
+			if match {
+				dr, derr := c.decompress(io.MultiReader(bytes.NewReader(header), r))
+				if derr != nil {
+					return nil, fmt.Errorf("reading %s stream: %v", c.name, derr)
+				}
+				return dr, nil
+			}
+			needMore = needMore || more
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if !needMore || err != nil || len(header) == sniffLen {
+			return io.NopCloser(io.MultiReader(bytes.NewReader(header), r)), nil
+		}
+	}
+}
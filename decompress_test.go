@@ -0,0 +1,60 @@
+package humanlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecompress(t *testing.T) {
+	plain := []byte("{\"msg\":\"hello\"}\n")
+
+	gzipped := bytes.NewBuffer(nil)
+	gzw := gzip.NewWriter(gzipped)
+	_, err := gzw.Write(plain)
+	require.NoError(t, err)
+	require.NoError(t, gzw.Close())
+
+	zstdEnc, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	zstded := zstdEnc.EncodeAll(plain, nil)
+
+	// `bzip2` of `plain`, the stdlib can't compress bzip2
+	bzipped, err := base64.StdEncoding.DecodeString("QlpoOTFBWSZTWf/3spYAAAdZgAAQEAAAEALGiAogACKYAeoQNA0CHNmxc1UBuF3JFOFCQ//eylg=")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		input []byte
+		want  []byte
+	}{
+		{name: "plain", input: plain, want: plain},
+		{name: "empty", input: nil, want: nil},
+		{name: "short", input: []byte("a\n"), want: []byte("a\n")},
+		{name: "looks like bzip2", input: []byte("BZh is not a log\n"), want: []byte("BZh is not a log\n")},
+		{name: "gzip", input: gzipped.Bytes(), want: plain},
+		{name: "zstd", input: zstded, want: plain},
+		{name: "bzip2", input: bzipped, want: plain},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, src := range []io.Reader{
+				bytes.NewReader(tt.input),
+				iotest.OneByteReader(bytes.NewReader(tt.input)),
+			} {
+				r, err := Decompress(src)
+				require.NoError(t, err)
+				got, err := io.ReadAll(r)
+				require.NoError(t, err)
+				require.Equal(t, string(tt.want), string(got))
+				require.NoError(t, r.Close())
+			}
+		})
+	}
+}
@@ -1,11 +1,48 @@
 package humanlog
 
 import (
+	"fmt"
 	"regexp"
 
 	typesv1 "github.com/minitape/api/go/types/v1"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
 )
 
+// restOfLineGroup is the group of a prefix pattern that holds the line
+// that was prefixed.
+const restOfLineGroup = "rest_of_line"
+
+// PrefixExtractor strips a prefix that tools like docker-compose or
+// kubectl put in front of the lines they print, and records what the
+// prefix tells about where the line comes from.
+type PrefixExtractor struct {
+	Name string
+	// Pattern matches a prefixed line. Its `rest_of_line` group must hold
+	// the line that was prefixed.
+	Pattern *regexp.Regexp
+	// Attributes maps named groups of Pattern to the attribute they are
+	// recorded as. Named groups that aren't mapped are recorded as
+	// attributes named after the group.
+	Attributes map[string]string
+	// ResourceAttributes maps named groups of Pattern to the resource
+	// attribute they are recorded as.
+	ResourceAttributes map[string]string
+}
+
+// NewPrefixExtractor makes an extractor out of `pattern`, which must
+// have a `rest_of_line` group. Its other named groups are recorded as
+// attributes.
+func NewPrefixExtractor(name, pattern string) (*PrefixExtractor, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if re.SubexpIndex(restOfLineGroup) < 0 {
+		return nil, fmt.Errorf("pattern must have a (?P<%s>...) group", restOfLineGroup)
+	}
+	return &PrefixExtractor{Name: name, Pattern: re}, nil
+}
+
 // dcLogsPrefixRe parses out a prefix like 'web_1 | ' from docker-compose
 // The regex exists of five parts:
 // 1. An optional color terminal escape sequence
@@ -15,30 +52,91 @@ import (
 // 5. The rest of the line
 var dcLogsPrefixRe = regexp.MustCompile("^(?:\x1b\\[\\d+m)?(?P<service_name>[a-zA-Z0-9._-]+)\\s+\\|(?:\x1b\\[0m)? (?P<rest_of_line>.*)$")
 
+// kubectlLogsPrefixRe parses out the prefix of `kubectl logs --prefix`,
+// like '[pod/web-7d4b9c8f6-x2x9z/nginx] '.
+var kubectlLogsPrefixRe = regexp.MustCompile(`^\[pod/(?P<pod>[^/\]]+)/(?P<container>[^\]]+)\] (?P<rest_of_line>.*)$`)
+
+// sternLogsPrefixRe parses out the prefix of stern and kubetail, like
+// 'web-7d4b9c8f6-x2x9z nginx '. The names are usually colored. Pod names
+// are required to have a dash, as pods managed by anything have one, and
+// a line that merely starts with two words shouldn't look prefixed.
+var sternLogsPrefixRe = regexp.MustCompile("^(?:\x1b\\[[\\d;]*m)?(?P<pod>[a-z0-9][a-z0-9.]*(?:-[a-z0-9.]+)+)(?:\x1b\\[[\\d;]*m)? (?:\x1b\\[[\\d;]*m)?(?P<container>[a-z0-9](?:[-a-z0-9]*[a-z0-9])?)(?:\x1b\\[[\\d;]*m)? (?P<rest_of_line>.*)$")
+
+var k8sResourceAttributes = map[string]string{
+	"pod":       string(semconv.K8SPodNameKey),
+	"container": string(semconv.K8SContainerNameKey),
+}
+
+// DefaultPrefixExtractors are the prefixes humanlog knows about.
+var DefaultPrefixExtractors = func() []*PrefixExtractor {
+	return []*PrefixExtractor{
+		{
+			Name:       "docker-compose",
+			Pattern:    dcLogsPrefixRe,
+			Attributes: map[string]string{"service_name": "service"},
+		},
+		{
+			Name:               "kubectl",
+			Pattern:            kubectlLogsPrefixRe,
+			ResourceAttributes: k8sResourceAttributes,
+		},
+		{
+			Name:               "stern",
+			Pattern:            sternLogsPrefixRe,
+			ResourceAttributes: k8sResourceAttributes,
+		},
+	}
+}
+
 type handler interface {
 	TryHandle([]byte, *typesv1.Log) bool
 }
 
-func tryDockerComposePrefix(d []byte, ev *typesv1.Log, nextHandler handler) bool {
-	matches := dcLogsPrefixRe.FindSubmatch(d)
-	if matches != nil {
-		if nextHandler.TryHandle(matches[2], ev) {
-			ev.Attributes = append(ev.Attributes, &typesv1.KV{
-				Key: "service", Value: typesv1.ValStr(string(matches[1])),
-			})
+func tryPrefixExtractors(d []byte, ev *typesv1.Log, extractors []*PrefixExtractor, nextHandler handler) bool {
+	for _, px := range extractors {
+		if px.tryHandle(d, ev, nextHandler) {
 			return true
 		}
+	}
+	return false
+}
+
+func (px *PrefixExtractor) tryHandle(d []byte, ev *typesv1.Log, nextHandler handler) bool {
+	matches := px.Pattern.FindSubmatch(d)
+	if matches == nil {
+		return false
+	}
+	rest := matches[px.Pattern.SubexpIndex(restOfLineGroup)]
+	if !nextHandler.TryHandle(rest, ev) {
 		// The Zap Development handler is only built for `JSONHandler`s so
 		// short-circuit calls for LogFmtHandlers
-		switch h := nextHandler.(type) {
-		case *JSONHandler:
-			if tryZapDevDCPrefix(matches[2], ev, h) {
-				ev.Attributes = append(ev.Attributes, &typesv1.KV{
-					Key: "service", Value: typesv1.ValStr(string(matches[1])),
-				})
-				return true
-			}
+		h, ok := nextHandler.(*JSONHandler)
+		if !ok || !tryZapDevDCPrefix(rest, ev, h) {
+			return false
 		}
 	}
-	return false
+
+	var resAttrs []*typesv1.KV
+	for i, group := range px.Pattern.SubexpNames() {
+		if group == "" || group == restOfLineGroup || matches[i] == nil {
+			continue
+		}
+		val := typesv1.ValStr(string(matches[i]))
+		if key, ok := px.ResourceAttributes[group]; ok {
+			resAttrs = append(resAttrs, typesv1.KeyVal(key, val))
+			continue
+		}
+		key := group
+		if mapped, ok := px.Attributes[group]; ok {
+			key = mapped
+		}
+		ev.Attributes = append(ev.Attributes, typesv1.KeyVal(key, val))
+	}
+	if len(resAttrs) > 0 {
+		if ev.Resource != nil {
+			resAttrs = append(ev.Resource.Attributes, resAttrs...)
+		}
+		ev.Resource = typesv1.NewResource(ev.Resource.GetSchemaUrl(), resAttrs)
+	}
+	return true
 }
@@ -0,0 +1,108 @@
+package humanlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestPrefixExtractors(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	custom, err := NewPrefixExtractor("custom", `^(?P<host>\w+): (?P<rest_of_line>.*)$`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		input   string
+		want    *typesv1.Log
+		wantErr bool
+	}{
+		{
+			name:  "docker-compose",
+			input: `web_1  | {"level":"info","msg":"hello","ts":"2024-01-01T00:00:00Z"}`,
+			want: &typesv1.Log{
+				Timestamp:    timestamppb.New(ts),
+				SeverityText: "info",
+				Body:         "hello",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("service", typesv1.ValStr("web_1")),
+				},
+			},
+		},
+		{
+			name:  "kubectl --prefix",
+			input: `[pod/web-7d4b9c8f6-x2x9z/nginx] level=info msg=hello ts=2024-01-01T00:00:00Z`,
+			want: &typesv1.Log{
+				Timestamp:    timestamppb.New(ts),
+				SeverityText: "info",
+				Body:         "hello",
+				Resource: typesv1.NewResource("", []*typesv1.KV{
+					typesv1.KeyVal("k8s.pod.name", typesv1.ValStr("web-7d4b9c8f6-x2x9z")),
+					typesv1.KeyVal("k8s.container.name", typesv1.ValStr("nginx")),
+				}),
+			},
+		},
+		{
+			name:  "stern",
+			input: "\x1b[32mweb-7d4b9c8f6-x2x9z\x1b[0m \x1b[34mnginx\x1b[0m " + `{"level":"info","msg":"hello","ts":"2024-01-01T00:00:00Z"}`,
+			want: &typesv1.Log{
+				Timestamp:    timestamppb.New(ts),
+				SeverityText: "info",
+				Body:         "hello",
+				Resource: typesv1.NewResource("", []*typesv1.KV{
+					typesv1.KeyVal("k8s.pod.name", typesv1.ValStr("web-7d4b9c8f6-x2x9z")),
+					typesv1.KeyVal("k8s.container.name", typesv1.ValStr("nginx")),
+				}),
+			},
+		},
+		{
+			name:  "custom",
+			input: `myhost: level=info msg=hello ts=2024-01-01T00:00:00Z`,
+			want: &typesv1.Log{
+				Timestamp:    timestamppb.New(ts),
+				SeverityText: "info",
+				Body:         "hello",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("host", typesv1.ValStr("myhost")),
+				},
+			},
+		},
+		{
+			name:    "prefixed line isn't structured",
+			input:   `[pod/web-7d4b9c8f6-x2x9z/nginx] just some text`,
+			wantErr: true,
+		},
+		{
+			name:    "two words aren't a stern prefix",
+			input:   `hello world {"msg":"hello"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.PrefixExtractors = append(opts.PrefixExtractors, custom)
+			jsonEntry := JSONHandler{Opts: opts}
+			logfmtEntry := LogfmtHandler{Opts: opts}
+
+			got := new(typesv1.Log)
+			ok := tryPrefixExtractors([]byte(tt.input), got, opts.PrefixExtractors, &jsonEntry) ||
+				tryPrefixExtractors([]byte(tt.input), got, opts.PrefixExtractors, &logfmtEntry)
+			if tt.wantErr {
+				require.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			diff := cmp.Diff(tt.want, got, protocmp.Transform())
+			require.Empty(t, diff)
+		})
+	}
+
+	_, err = NewPrefixExtractor("bad", `^(?P<host>\w+): `)
+	require.Error(t, err)
+}
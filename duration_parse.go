@@ -0,0 +1,105 @@
+package humanlog
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tryParseDurationString parses durations written like Go prints them,
+// like `150ms` or `1h2m3.5s`, or in ISO 8601, like `PT1M30S`.
+func tryParseDurationString(s string) (time.Duration, bool) {
+	if s == "" {
+		return 0, false
+	}
+	if d, ok := parseISO8601Duration(s); ok {
+		return d, true
+	}
+	if (s[0] < '0' || s[0] > '9') && s[0] != '-' && s[0] != '.' {
+		return 0, false
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || s == "0" {
+		return 0, false
+	}
+	return d, true
+}
+
+// iso8601DurationRe matches the ISO 8601 durations that have a definite
+// length, so without years or months.
+var iso8601DurationRe = regexp.MustCompile(`^(-)?P(?:(\d+(?:[.,]\d+)?)W)?(?:(\d+(?:[.,]\d+)?)D)?(?:T(?:(\d+(?:[.,]\d+)?)H)?(?:(\d+(?:[.,]\d+)?)M)?(?:(\d+(?:[.,]\d+)?)S)?)?$`)
+
+func parseISO8601Duration(s string) (time.Duration, bool) {
+	if len(s) < 3 || (s[0] != 'P' && s[0] != '-') {
+		return 0, false
+	}
+	m := iso8601DurationRe.FindStringSubmatch(s)
+	if m == nil || strings.HasSuffix(s, "T") {
+		return 0, false
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var (
+		d     float64
+		found bool
+	)
+	for i, unit := range units {
+		if m[i+2] == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.Replace(m[i+2], ",", ".", 1), 64)
+		if err != nil {
+			return 0, false
+		}
+		d += v * float64(unit)
+		found = true
+	}
+	if !found {
+		return 0, false
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return time.Duration(d), true
+}
+
+// durationUnitSuffixes are how fields holding a number of some unit of
+// time tend to end, like `elapsed_ns` or `durationMs`.
+var durationUnitSuffixes = []struct {
+	suffix string
+	unit   time.Duration
+}{
+	{"ns", time.Nanosecond},
+	{"nanos", time.Nanosecond},
+	{"us", time.Microsecond},
+	{"µs", time.Microsecond},
+	{"micros", time.Microsecond},
+	{"ms", time.Millisecond},
+	{"millis", time.Millisecond},
+	{"sec", time.Second},
+	{"secs", time.Second},
+	{"seconds", time.Second},
+}
+
+// durationUnitOf tells which unit of time the numbers of the field `key`
+// are in, judging by its name.
+func durationUnitOf(key string) (time.Duration, bool) {
+	if i := strings.LastIndexByte(key, '.'); i >= 0 {
+		key = key[i+1:]
+	}
+	for _, s := range durationUnitSuffixes {
+		n := len(key) - len(s.suffix)
+		if n < 1 {
+			continue
+		}
+		// snake_case or kebab-case
+		if strings.EqualFold(key[n:], s.suffix) && (key[n-1] == '_' || key[n-1] == '-') {
+			return s.unit, true
+		}
+		// camelCase
+		if key[n:] == strings.ToUpper(s.suffix[:1])+s.suffix[1:] && key[n-1] >= 'a' && key[n-1] <= 'z' {
+			return s.unit, true
+		}
+	}
+	return 0, false
+}
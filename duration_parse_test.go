@@ -0,0 +1,68 @@
+package humanlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTryParseDurationString(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   time.Duration
+		wantOK bool
+	}{
+		{in: "250ms", want: 250 * time.Millisecond, wantOK: true},
+		{in: "1h2m3.5s", want: time.Hour + 2*time.Minute + 3500*time.Millisecond, wantOK: true},
+		{in: "-3s", want: -3 * time.Second, wantOK: true},
+		{in: "953.2µs", want: 953200 * time.Nanosecond, wantOK: true},
+		{in: "PT1M30S", want: 90 * time.Second, wantOK: true},
+		{in: "PT0.5S", want: 500 * time.Millisecond, wantOK: true},
+		{in: "P1DT2H", want: 26 * time.Hour, wantOK: true},
+		{in: "P2W", want: 14 * 24 * time.Hour, wantOK: true},
+		{in: "-PT1H", want: -time.Hour, wantOK: true},
+		{in: "P1Y"},
+		{in: "PT"},
+		{in: "P"},
+		{in: "0"},
+		{in: "ms"},
+		{in: "fast"},
+		{in: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := tryParseDurationString(tt.in)
+			require.Equal(t, tt.wantOK, ok)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDurationUnitOf(t *testing.T) {
+	tests := []struct {
+		key    string
+		want   time.Duration
+		wantOK bool
+	}{
+		{key: "elapsed_ns", want: time.Nanosecond, wantOK: true},
+		{key: "latency_us", want: time.Microsecond, wantOK: true},
+		{key: "duration_ms", want: time.Millisecond, wantOK: true},
+		{key: "took-millis", want: time.Millisecond, wantOK: true},
+		{key: "timeout_seconds", want: time.Second, wantOK: true},
+		{key: "req.duration_MS", want: time.Millisecond, wantOK: true},
+		{key: "durationMs", want: time.Millisecond, wantOK: true},
+		{key: "elapsedSeconds", want: time.Second, wantOK: true},
+		{key: "items"},
+		{key: "ms"},
+		{key: "params"},
+		{key: "DMS"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, ok := durationUnitOf(tt.key)
+			require.Equal(t, tt.wantOK, ok)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
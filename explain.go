@@ -0,0 +1,122 @@
+package humanlog
+
+import (
+	"slices"
+
+	typesv1 "github.com/minitape/api/go/types/v1"
+)
+
+// Explanation tells how a line was parsed.
+type Explanation struct {
+	Line []byte
+	// Log is the event parsed out of the line, nil when the line didn't
+	// complete one, like the header of a CSV file.
+	Log *typesv1.Log
+	// Handler is the handler that recognized the line, like `json`, or
+	// `container > logfmt` for a handler within another. It's empty
+	// when none did.
+	Handler string
+	// Rejected are the handlers tried before, that didn't recognize the
+	// line, in the order they were tried.
+	Rejected []string
+	// Lookups are the field lists of the handler options that were
+	// consulted, like `TimeFields`.
+	Lookups []*FieldLookup
+}
+
+// FieldLookup tells what was found in a field list of the handler
+// options.
+type FieldLookup struct {
+	// Option is the name of the handler option, like `TimeFields`.
+	Option string
+	// Fields is the list as it was consulted first. Lists are reordered
+	// as fields are found, so that the next lines find them first.
+	Fields []string
+	// Found is the field that was found, empty if none was.
+	Found string
+}
+
+// Explainer parses lines like `Scan` does, explaining how it parses
+// them. Like `Scan`, it learns from the lines it parses how the next
+// ones are formatted, so the lines must be given in order. Lines are
+// explained one at a time, multiline events aren't put together.
+type Explainer struct {
+	parse func([]byte, *typesv1.Log) bool
+	x     *explainer
+}
+
+// NewExplainer explains the parsing of lines with `opts`.
+func NewExplainer(opts *HandlerOptions) *Explainer {
+	x := new(explainer)
+	explained := *opts
+	explained.explain = x
+	return &Explainer{parse: newLineParser(&explained), x: x}
+}
+
+// Explain parses `line`, explaining how.
+func (e *Explainer) Explain(line []byte) *Explanation {
+	e.x.cur = &Explanation{Line: line}
+	ev := new(typesv1.Log)
+	if e.parse(line, ev) {
+		e.x.cur.Log = ev
+	}
+	return e.x.cur
+}
+
+// explainer records how a line is parsed. Its methods do nothing on a
+// nil explainer, which is what handlers have when nothing is explained.
+type explainer struct {
+	cur    *Explanation
+	within []string
+}
+
+// enter tells that the handlers tried next are within `handler`.
+func (x *explainer) enter(handler string) {
+	if x == nil {
+		return
+	}
+	x.within = append(x.within, handler)
+}
+
+func (x *explainer) leave() {
+	if x == nil {
+		return
+	}
+	x.within = x.within[:len(x.within)-1]
+}
+
+func (x *explainer) tried(handler string, handled bool) {
+	if x == nil || x.cur == nil {
+		return
+	}
+	name := handler
+	for i := len(x.within) - 1; i >= 0; i-- {
+		name = x.within[i] + " > " + name
+	}
+	switch {
+	case !handled:
+		x.cur.Rejected = append(x.cur.Rejected, name)
+	case x.cur.Handler == "":
+		// handlers within others are done first
+		x.cur.Handler = name
+	}
+}
+
+func (x *explainer) lookedUp(option string, fields []string, found string) {
+	if x == nil || x.cur == nil || len(fields) == 0 {
+		return
+	}
+	for _, lookup := range x.cur.Lookups {
+		if lookup.Option == option {
+			if lookup.Found == "" {
+				lookup.Found = found
+			}
+			return
+		}
+	}
+	x.cur.Lookups = append(x.cur.Lookups, &FieldLookup{
+		Option: option,
+		Fields: slices.Clone(fields),
+		Found:  found,
+	})
+}
@@ -0,0 +1,46 @@
+package humanlog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainer(t *testing.T) {
+	opts := DefaultOptions()
+	opts.TextFormats = nil
+	opts.TimeFields = []string{"time", "ts"}
+	opts.MessageFields = []string{"message", "msg"}
+	opts.LevelFields = []string{"level"}
+
+	ex := NewExplainer(opts)
+
+	got := ex.Explain([]byte(`{"ts":"2024-10-11T15:25:06Z","level":"info","msg":"hello"}`))
+	require.NotNil(t, got.Log)
+	require.Equal(t, "hello", got.Log.Body)
+	require.Equal(t, "json", got.Handler)
+	require.Equal(t, []string{"container", "table"}, got.Rejected)
+	require.Equal(t, []*FieldLookup{
+		{Option: "TimeFields", Fields: []string{"time", "ts"}, Found: "ts"},
+		{Option: "LevelFields", Fields: []string{"level"}, Found: "level"},
+		{Option: "MessageFields", Fields: []string{"message", "msg"}, Found: "msg"},
+	}, got.Lookups)
+
+	got = ex.Explain([]byte(`level=warn message=bye`))
+	require.Equal(t, "logfmt", got.Handler)
+	require.Equal(t, []string{"container", "table", "json", "syslog"}, got.Rejected)
+	require.Equal(t, []*FieldLookup{
+		// `ts` was found first in the previous line
+		{Option: "TimeFields", Fields: []string{"ts", "time"}},
+		{Option: "MessageFields", Fields: []string{"msg", "message"}, Found: "message"},
+		{Option: "LevelFields", Fields: []string{"level"}, Found: "level"},
+	}, got.Lookups)
+
+	got = ex.Explain([]byte(`{"log":"{\"level\":\"info\",\"msg\":\"wrapped\"}\n","stream":"stdout","time":"2024-10-11T15:25:06Z"}`))
+	require.Equal(t, "container > json", got.Handler)
+	require.Equal(t, "wrapped", got.Log.Body)
+
+	got = ex.Explain([]byte(`just some text`))
+	require.Empty(t, got.Handler)
+	require.False(t, got.Log.IsStructured())
+}
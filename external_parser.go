@@ -0,0 +1,297 @@
+package humanlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"sync"
+	"time"
+
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// The encodings of the events that external parsers reply with.
+const (
+	// ExternalProtoJSON events are `typesv1.Log` messages in protojson,
+	// one per line.
+	ExternalProtoJSON = "protojson"
+	// ExternalDelimited events are binary `typesv1.Log` messages, each
+	// preceded by its size as a varint.
+	ExternalDelimited = "delimited"
+)
+
+// PriorityExternal is the priority of external parsers, which are tried
+// before every built-in handler, as they're given only the lines they
+// match.
+const PriorityExternal = 700
+
+const defaultExternalTimeout = 5 * time.Second
+
+// ExternalParser parses lines with an executable of its own. The lines it
+// matches are written to the standard input of the executable, one per
+// line, and the executable replies to each with one event. An event that
+// isn't structured, like `{}`, tells that the line isn't recognized, and
+// the other handlers get to try it.
+//
+// The executable is started with the first line it's given, and is
+// shared by every handler chain made with the options holding it.
+type ExternalParser struct {
+	Name string
+	// Command is the executable and its arguments.
+	Command []string
+	// Prefix and Match pick the lines given to the executable, either
+	// those starting with Prefix or those that Match matches. All lines
+	// are given when neither is set.
+	Prefix string
+	Match  *regexp.Regexp
+	// Encoding is how the executable replies, ExternalProtoJSON when
+	// empty.
+	Encoding string
+	// Timeout bounds how long the executable takes to read a line and
+	// reply to it, 5s when zero. An executable that takes longer is
+	// stopped.
+	Timeout time.Duration
+	// OnError is told why the executable was stopped. External parsers
+	// that fail aren't tried again.
+	OnError func(error)
+
+	mu      sync.Mutex
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	replies chan externalReply
+	failed  bool
+}
+
+type externalReply struct {
+	ev  *typesv1.Log
+	err error
+}
+
+// NewExternalParser runs `command` to parse lines. `encoding` is one of
+// ExternalProtoJSON and ExternalDelimited, or empty for the former.
+func NewExternalParser(name string, command []string, encoding string) (*ExternalParser, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("no command to run")
+	}
+	switch encoding {
+	case "", ExternalProtoJSON, ExternalDelimited:
+	default:
+		return nil, fmt.Errorf("unknown encoding %q, must be one of %v", encoding, []string{ExternalProtoJSON, ExternalDelimited})
+	}
+	return &ExternalParser{Name: name, Command: command, Encoding: encoding}, nil
+}
+
+func (ep *ExternalParser) matches(d []byte) bool {
+	switch {
+	case ep.Prefix != "" && !bytes.HasPrefix(d, []byte(ep.Prefix)):
+		return false
+	case ep.Match != nil && !ep.Match.Match(d):
+		return false
+	}
+	return true
+}
+
+// TryHandle has the executable parse `d` into `ev`, if it matches.
+func (ep *ExternalParser) TryHandle(d []byte, ev *typesv1.Log) bool {
+	if !ep.matches(d) || bytes.IndexByte(d, '\n') >= 0 {
+		return false
+	}
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	if ep.failed {
+		return false
+	}
+	parsed, err := ep.parse(d)
+	if err != nil {
+		ep.fail(err)
+		return false
+	}
+	if !parsed.IsStructured() {
+		return false
+	}
+	// what's known of the line regardless of its format is kept
+	parsed.Raw, parsed.Ulid, parsed.ObservedTimestamp = nil, nil, nil
+	proto.Merge(ev, parsed)
+	return true
+}
+
+func (ep *ExternalParser) parse(d []byte) (*typesv1.Log, error) {
+	if ep.cmd == nil {
+		if err := ep.start(); err != nil {
+			return nil, err
+		}
+	}
+	timeout := ep.Timeout
+	if timeout == 0 {
+		timeout = defaultExternalTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	// an executable that stops reading would block the write
+	line := make([]byte, 0, len(d)+1)
+	line = append(append(line, d...), '\n')
+	written := make(chan error, 1)
+	go func() {
+		_, err := ep.stdin.Write(line)
+		written <- err
+	}()
+	select {
+	case err := <-written:
+		if err != nil {
+			return nil, fmt.Errorf("writing to parser: %v", err)
+		}
+	case <-timer.C:
+		return nil, fmt.Errorf("parser didn't read within %v", timeout)
+	}
+	select {
+	case reply, ok := <-ep.replies:
+		if !ok {
+			return nil, fmt.Errorf("parser exited")
+		}
+		return reply.ev, reply.err
+	case <-timer.C:
+		return nil, fmt.Errorf("parser didn't reply within %v", timeout)
+	}
+}
+
+func (ep *ExternalParser) start() error {
+	cmd := exec.Command(ep.Command[0], ep.Command[1:]...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting parser: %v", err)
+	}
+	ep.cmd, ep.stdin = cmd, stdin
+	ep.replies = make(chan externalReply)
+	go ep.readReplies(bufio.NewReader(stdout), ep.replies)
+	return nil
+}
+
+// readReplies decodes the replies of the executable until it stops.
+func (ep *ExternalParser) readReplies(r *bufio.Reader, replies chan<- externalReply) {
+	defer close(replies)
+	for {
+		ev := new(typesv1.Log)
+		var err error
+		if ep.Encoding == ExternalDelimited {
+			err = readDelimited(r, ev)
+		} else {
+			err = readProtoJSON(r, ev)
+		}
+		if errors.Is(err, io.EOF) {
+			err = fmt.Errorf("parser exited")
+		}
+		replies <- externalReply{ev: ev, err: err}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func readProtoJSON(r *bufio.Reader, ev *typesv1.Log) error {
+	line, err := r.ReadBytes('\n')
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		return err
+	}
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		// an empty reply is a line that isn't recognized
+		return nil
+	}
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(line, ev); err != nil {
+		return fmt.Errorf("decoding reply: %v", err)
+	}
+	return nil
+}
+
+func readDelimited(r *bufio.Reader, ev *typesv1.Log) error {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return err
+	}
+	if size > maxBufferSize {
+		return fmt.Errorf("reply of %d bytes is too large", size)
+	}
+	d := make([]byte, size)
+	if _, err := io.ReadFull(r, d); err != nil {
+		return err
+	}
+	if err := proto.Unmarshal(d, ev); err != nil {
+		return fmt.Errorf("decoding reply: %v", err)
+	}
+	return nil
+}
+
+// fail stops the executable, for good.
+func (ep *ExternalParser) fail(err error) {
+	ep.failed = true
+	ep.stop()
+	if ep.OnError != nil {
+		ep.OnError(fmt.Errorf("external parser %q: %v", ep.Name, err))
+	}
+}
+
+func (ep *ExternalParser) stop() {
+	if ep.cmd == nil {
+		return
+	}
+	ep.release()
+	_ = ep.cmd.Process.Kill()
+	_ = ep.cmd.Wait()
+	ep.cmd = nil
+}
+
+// release tells the executable that no more lines are coming, and
+// discards the replies that are still to come.
+func (ep *ExternalParser) release() {
+	_ = ep.stdin.Close()
+	go func(replies <-chan externalReply) {
+		for range replies {
+		}
+	}(ep.replies)
+}
+
+// Close stops the executable, letting it exit on its own first.
+func (ep *ExternalParser) Close() error {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	if ep.cmd == nil {
+		return nil
+	}
+	ep.release()
+	done := make(chan error, 1)
+	go func() { done <- ep.cmd.Wait() }()
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(time.Second):
+		_ = ep.cmd.Process.Kill()
+		err = <-done
+	}
+	ep.cmd = nil
+	ep.failed = true
+	return err
+}
+
+func externalHandlers(opts *HandlerOptions) []namedHandler {
+	var handlers []namedHandler
+	for _, ep := range opts.ExternalParsers {
+		handlers = append(handlers, namedHandler{name: "external:" + ep.Name, try: ep.TryHandle})
+	}
+	return handlers
+}
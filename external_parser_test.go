@@ -0,0 +1,170 @@
+package humanlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const externalParserEnv = "HUMANLOG_TEST_EXTERNAL_PARSER"
+
+// TestExternalParserProcess isn't a test, it's the executable of the
+// external parsers of TestExternalParser. It recognizes lines like
+// `ACME|level|message`, and never replies to `ACME|hang`.
+func TestExternalParserProcess(t *testing.T) {
+	encoding := os.Getenv(externalParserEnv)
+	switch encoding {
+	case "":
+		return
+	case "stall":
+		// never reads its input
+		time.Sleep(time.Minute)
+		os.Exit(0)
+	}
+	in := bufio.NewScanner(os.Stdin)
+	out := bufio.NewWriter(os.Stdout)
+	for in.Scan() {
+		ev := new(typesv1.Log)
+		rest, ok := bytes.CutPrefix(in.Bytes(), []byte("ACME|"))
+		if string(rest) == "hang" {
+			time.Sleep(time.Minute)
+		}
+		if level, msg, found := bytes.Cut(rest, []byte("|")); ok && found {
+			ev.SeverityText, ev.Body = string(level), string(msg)
+			ev.Attributes = []*typesv1.KV{typesv1.KeyVal("parser", typesv1.ValStr("acme"))}
+		}
+		if encoding == ExternalDelimited {
+			d, _ := proto.Marshal(ev)
+			_, _ = out.Write(binary.AppendUvarint(nil, uint64(len(d))))
+			_, _ = out.Write(d)
+		} else {
+			d, _ := protojson.Marshal(ev)
+			_, _ = out.Write(append(d, '\n'))
+		}
+		_ = out.Flush()
+	}
+	os.Exit(0)
+}
+
+func TestExternalParser(t *testing.T) {
+	tests := []struct {
+		name        string
+		encoding    string
+		prefix      string
+		timeout     time.Duration
+		input       []string
+		wantHandler []string
+		wantBody    []string
+		wantErr     bool
+	}{
+		{
+			name:        "protojson",
+			encoding:    ExternalProtoJSON,
+			input:       []string{"ACME|warn|disk full", "ACME|info|disk ok"},
+			wantHandler: []string{"external:acme", "external:acme"},
+			wantBody:    []string{"disk full", "disk ok"},
+		},
+		{
+			name:        "delimited",
+			encoding:    ExternalDelimited,
+			input:       []string{"ACME|warn|disk full", "ACME|info|disk ok"},
+			wantHandler: []string{"external:acme", "external:acme"},
+			wantBody:    []string{"disk full", "disk ok"},
+		},
+		{
+			name:        "not recognized",
+			encoding:    ExternalProtoJSON,
+			input:       []string{"level=info msg=hello", "ACME|warn|disk full"},
+			wantHandler: []string{"logfmt", "external:acme"},
+			wantBody:    []string{"hello", "disk full"},
+		},
+		{
+			name:        "not recognized delimited",
+			encoding:    ExternalDelimited,
+			input:       []string{`{"level":"info","msg":"hello"}`, "ACME|warn|disk full"},
+			wantHandler: []string{"json", "external:acme"},
+			wantBody:    []string{"hello", "disk full"},
+		},
+		{
+			name:        "not matched",
+			encoding:    ExternalProtoJSON,
+			prefix:      "OTHER",
+			input:       []string{"ACME|warn|disk full"},
+			wantHandler: []string{""},
+			wantBody:    []string{""},
+		},
+		{
+			name:        "timed out",
+			encoding:    ExternalProtoJSON,
+			timeout:     100 * time.Millisecond,
+			input:       []string{"ACME|hang", "ACME|warn|disk full"},
+			wantHandler: []string{"", ""},
+			wantBody:    []string{"", ""},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(externalParserEnv, tt.encoding)
+			ep, err := NewExternalParser("acme", []string{os.Args[0], "-test.run=^TestExternalParserProcess$"}, tt.encoding)
+			require.NoError(t, err)
+			ep.Prefix = tt.prefix
+			ep.Timeout = tt.timeout
+			var errs []error
+			ep.OnError = func(err error) { errs = append(errs, err) }
+			t.Cleanup(func() { _ = ep.Close() })
+
+			opts := DefaultOptions()
+			opts.ExternalParsers = []*ExternalParser{ep}
+			ex := NewExplainer(opts)
+			for i, line := range tt.input {
+				got := ex.Explain([]byte(line))
+				require.Equal(t, tt.wantHandler[i], got.Handler, line)
+				require.Equal(t, tt.wantBody[i], got.Log.GetBody(), line)
+				if got.Handler == "external:acme" {
+					require.Equal(t, []byte(line), got.Log.Raw)
+					require.Equal(t, "acme", got.Log.FindAttr("parser").GetStr())
+				}
+			}
+			require.Equal(t, tt.wantErr, len(errs) > 0, "%v", errs)
+		})
+	}
+}
+
+func TestExternalParserStalled(t *testing.T) {
+	t.Setenv(externalParserEnv, "stall")
+	ep, err := NewExternalParser("acme", []string{os.Args[0], "-test.run=^TestExternalParserProcess$"}, "")
+	require.NoError(t, err)
+	ep.Timeout = 100 * time.Millisecond
+	var errs []error
+	ep.OnError = func(err error) { errs = append(errs, err) }
+	t.Cleanup(func() { _ = ep.Close() })
+
+	// more than a pipe holds, for the write to block
+	line := "ACME|info|" + strings.Repeat("a", 1<<20)
+	done := make(chan bool)
+	go func() { done <- ep.TryHandle([]byte(line), new(typesv1.Log)) }()
+	select {
+	case handled := <-done:
+		require.False(t, handled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("writing to a stalled parser blocked")
+	}
+	require.Len(t, errs, 1)
+}
+
+func TestNewExternalParser(t *testing.T) {
+	_, err := NewExternalParser("acme", nil, "")
+	require.Error(t, err)
+	_, err = NewExternalParser("acme", []string{"acme"}, "xml")
+	require.Error(t, err)
+}
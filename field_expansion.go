@@ -0,0 +1,336 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-logfmt/logfmt"
+	typesv1 "github.com/minitape/api/go/types/v1"
+)
+
+// The encodings of the documents that fields can be expanded from.
+const (
+	ExpandJSON       = "json"
+	ExpandLogfmt     = "logfmt"
+	ExpandQuery      = "query"
+	ExpandBase64JSON = "base64"
+)
+
+var allExpandFormats = []string{ExpandJSON, ExpandLogfmt, ExpandQuery, ExpandBase64JSON}
+
+// maxExpandDepth bounds how deeply documents found within expanded
+// documents are expanded.
+const maxExpandDepth = 8
+
+// FieldExpansion decodes the values of a field that are documents of
+// their own, like the payload of `{"payload":"{\"user\":42}"}`, into
+// nested attributes.
+type FieldExpansion struct {
+	// Field is the name of the field. Naming a message field expands
+	// messages that are documents into the event itself.
+	Field string
+	// Formats are the encodings tried, in order, among ExpandJSON,
+	// ExpandLogfmt, ExpandQuery and ExpandBase64JSON. All of them are
+	// tried when empty.
+	Formats []string
+}
+
+// NewFieldExpansion expands `field` from any of `formats`, or from all
+// of them when there are none.
+func NewFieldExpansion(field string, formats []string) (*FieldExpansion, error) {
+	if field == "" {
+		return nil, fmt.Errorf("no field to expand")
+	}
+	for _, format := range formats {
+		if !slices.Contains(allExpandFormats, format) {
+			return nil, fmt.Errorf("unknown format %q, must be one of %v", format, allExpandFormats)
+		}
+	}
+	return &FieldExpansion{Field: field, Formats: formats}, nil
+}
+
+func (exp *FieldExpansion) formats() []string {
+	if len(exp.Formats) == 0 {
+		return allExpandFormats
+	}
+	return exp.Formats
+}
+
+// fieldExpander expands the fields of events, as told by the
+// `Expand` handler option.
+type fieldExpander struct {
+	opts *HandlerOptions
+
+	// json and logfmt decode documents without promoting any of their
+	// fields
+	json   JSONHandler
+	logfmt LogfmtHandler
+	// msgJSON and msgLogfmt decode messages, like they would lines
+	msgJSON   JSONHandler
+	msgLogfmt LogfmtHandler
+}
+
+func newFieldExpander(opts *HandlerOptions) *fieldExpander {
+	inner := *opts
+	inner.TimeFields, inner.MessageFields, inner.LevelFields = nil, nil, nil
+	inner.KeepNested = true
+	return &fieldExpander{
+		opts:      opts,
+		json:      JSONHandler{Opts: &inner},
+		logfmt:    LogfmtHandler{Opts: &inner},
+		msgJSON:   JSONHandler{Opts: opts},
+		msgLogfmt: LogfmtHandler{Opts: opts},
+	}
+}
+
+func (fx *fieldExpander) expand(ev *typesv1.Log) {
+	for _, exp := range fx.opts.Expand {
+		if isOneOf(exp.Field, fx.opts.MessageFields) {
+			fx.expandMessage(ev, exp.formats())
+			continue
+		}
+		for i := 0; i < len(ev.Attributes); i++ {
+			kv := ev.Attributes[i]
+			if !fieldsEqualAllString(kv.Key, exp.Field) {
+				continue
+			}
+			doc, ok := fx.decode(kv.Value.GetStr(), exp.formats(), 0)
+			if !ok {
+				continue
+			}
+			attrs := fx.attributesOf(kv.Key, doc)
+			ev.Attributes = slices.Replace(ev.Attributes, i, i+1, attrs...)
+			i += len(attrs) - 1
+		}
+	}
+}
+
+// expandMessage decodes a message that is a document as if it was the
+// line, its fields completing those of the event.
+func (fx *fieldExpander) expandMessage(ev *typesv1.Log, formats []string) {
+	body := []byte(strings.TrimSpace(ev.Body))
+	if len(body) == 0 {
+		return
+	}
+	doc := new(typesv1.Log)
+	for _, format := range formats {
+		var ok bool
+		switch format {
+		case ExpandJSON:
+			ok = body[0] == '{' && fx.msgJSON.TryHandle(body, doc)
+		case ExpandLogfmt:
+			ok = isStrictLogfmt(body) && fx.msgLogfmt.TryHandle(body, doc)
+		case ExpandQuery:
+			var v *typesv1.Val
+			if v, ok = decodeQuery(string(body)); ok {
+				doc.Attributes = fx.attributesOf("", v)
+			}
+		case ExpandBase64JSON:
+			if decoded, isBase64 := decodeBase64(string(body)); isBase64 && decoded[0] == '{' {
+				ok = fx.msgJSON.TryHandle(decoded, doc)
+			}
+		}
+		if !ok {
+			continue
+		}
+		ev.Body = doc.Body
+		if ev.SeverityText == "" {
+			ev.SeverityText = doc.SeverityText
+		}
+		if ev.Timestamp == nil {
+			ev.Timestamp = doc.Timestamp
+		}
+		ev.Attributes = append(ev.Attributes, doc.Attributes...)
+		return
+	}
+}
+
+// attributesOf makes `doc` the attributes of the field `key`, either
+// nested or flattened, as the options tell.
+func (fx *fieldExpander) attributesOf(key string, doc *typesv1.Val) []*typesv1.KV {
+	if fx.opts.KeepNested {
+		if key == "" {
+			return doc.GetObj().GetKvs()
+		}
+		return []*typesv1.KV{typesv1.KeyVal(key, doc)}
+	}
+	return flattenVal(key, doc, nil)
+}
+
+// decode decodes `s` into an object or an array, with the first of
+// `formats` that can. The strings it holds are decoded too.
+func (fx *fieldExpander) decode(s string, formats []string, depth int) (*typesv1.Val, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || depth > maxExpandDepth {
+		return nil, false
+	}
+	for _, format := range formats {
+		var (
+			doc *typesv1.Val
+			ok  bool
+		)
+		switch format {
+		case ExpandJSON:
+			doc, ok = fx.decodeJSON([]byte(s))
+		case ExpandLogfmt:
+			doc, ok = fx.decodeLogfmt([]byte(s))
+		case ExpandQuery:
+			doc, ok = decodeQuery(s)
+		case ExpandBase64JSON:
+			if decoded, isBase64 := decodeBase64(s); isBase64 {
+				doc, ok = fx.decodeJSON(decoded)
+			}
+		}
+		if ok {
+			fx.decodeWithin(doc, formats, depth+1)
+			retype(doc)
+			return doc, true
+		}
+	}
+	return nil, false
+}
+
+func (fx *fieldExpander) decodeWithin(doc *typesv1.Val, formats []string, depth int) {
+	switch {
+	case doc.GetObj() != nil:
+		for _, kv := range doc.GetObj().Kvs {
+			if inner, ok := fx.decode(kv.Value.GetStr(), formats, depth); ok {
+				kv.Value = inner
+			} else {
+				fx.decodeWithin(kv.Value, formats, depth)
+			}
+		}
+	case doc.GetArr() != nil:
+		items := doc.GetArr().Items
+		for i, item := range items {
+			if inner, ok := fx.decode(item.GetStr(), formats, depth); ok {
+				items[i] = inner
+			} else {
+				fx.decodeWithin(item, formats, depth)
+			}
+		}
+	}
+}
+
+func (fx *fieldExpander) decodeJSON(d []byte) (*typesv1.Val, bool) {
+	if len(d) == 0 || (d[0] != '{' && d[0] != '[') {
+		return nil, false
+	}
+	// the handler only takes objects, so arrays are wrapped in one
+	wrapped := make([]byte, 0, len(d)+6)
+	wrapped = append(wrapped, `{"v":`...)
+	wrapped = append(wrapped, d...)
+	wrapped = append(wrapped, '}')
+	if !fx.json.TryHandle(wrapped, new(typesv1.Log)) || len(fx.json.Fields) != 1 {
+		return nil, false
+	}
+	return fx.json.Fields[0].Value, true
+}
+
+func (fx *fieldExpander) decodeLogfmt(d []byte) (*typesv1.Val, bool) {
+	if !isStrictLogfmt(d) || !fx.logfmt.TryHandle(d, new(typesv1.Log)) {
+		return nil, false
+	}
+	return typesv1.ValObj(fx.logfmt.Fields...), true
+}
+
+// isStrictLogfmt tells if `d` is logfmt where every key has a value, as
+// about any text is logfmt otherwise.
+func isStrictLogfmt(d []byte) bool {
+	if !bytes.ContainsRune(d, '=') {
+		return false
+	}
+	dec := logfmt.NewDecoder(bytes.NewReader(d))
+	for dec.ScanRecord() {
+		for dec.ScanKeyval() {
+			if dec.Value() == nil {
+				return false
+			}
+		}
+	}
+	return dec.Err() == nil
+}
+
+// decodeQuery decodes URL query strings, like `a=1&b=2`, keeping the
+// order of their keys. Repeated keys hold arrays.
+func decodeQuery(s string) (*typesv1.Val, bool) {
+	s = strings.TrimPrefix(s, "?")
+	if !strings.Contains(s, "=") || strings.ContainsAny(s, " \t\n") {
+		return nil, false
+	}
+	var (
+		keys   []string
+		values = make(map[string][]*typesv1.Val)
+	)
+	for _, pair := range strings.Split(s, "&") {
+		if pair == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(k)
+		if err != nil || key == "" {
+			return nil, false
+		}
+		value, err := url.QueryUnescape(v)
+		if err != nil {
+			return nil, false
+		}
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = append(values[key], typesv1.ValStr(value))
+	}
+	kvs := make([]*typesv1.KV, 0, len(keys))
+	for _, key := range keys {
+		if vals := values[key]; len(vals) == 1 {
+			kvs = append(kvs, typesv1.KeyVal(key, vals[0]))
+		} else {
+			kvs = append(kvs, typesv1.KeyVal(key, typesv1.ValArr(vals...)))
+		}
+	}
+	return typesv1.ValObj(kvs...), len(kvs) > 0
+}
+
+// decodeBase64 decodes `s` if it's base64 encoded, with or without
+// padding, in the standard or the URL alphabet.
+func decodeBase64(s string) ([]byte, bool) {
+	if len(s) < 4 {
+		return nil, false
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if d, err := enc.DecodeString(s); err == nil {
+			d = bytes.TrimSpace(d)
+			return d, len(d) > 0
+		}
+	}
+	return nil, false
+}
+
+// flattenVal appends `v` to `out` as attributes with dotted keys, like
+// `a.b.0`, under `prefix`.
+func flattenVal(prefix string, v *typesv1.Val, out []*typesv1.KV) []*typesv1.KV {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch {
+	case v.GetObj() != nil:
+		for _, kv := range v.GetObj().Kvs {
+			out = flattenVal(join(kv.Key), kv.Value, out)
+		}
+	case v.GetArr() != nil:
+		for i, item := range v.GetArr().Items {
+			out = flattenVal(join(strconv.Itoa(i)), item, out)
+		}
+	default:
+		out = append(out, typesv1.KeyVal(prefix, v))
+	}
+	return out
+}
@@ -0,0 +1,182 @@
+package humanlog
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"github.com/humanlogio/humanlog/pkg/sink/bufsink"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestFieldExpansion(t *testing.T) {
+	now := time.Date(2024, 10, 11, 15, 25, 6, 0, time.UTC)
+	tests := []struct {
+		name       string
+		input      string
+		expand     []*FieldExpansion
+		keepNested bool
+		want       *typesv1.Log
+	}{
+		{
+			name:   "json object",
+			input:  `{"msg":"request","payload":"{\"user\":42,\"tags\":[\"a\",\"b\"]}","other":"{\"x\":1}"}`,
+			expand: []*FieldExpansion{{Field: "payload"}},
+			want: &typesv1.Log{
+				Body: "request",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("payload.user", typesv1.ValI64(42)),
+					typesv1.KeyVal("payload.tags.0", typesv1.ValStr("a")),
+					typesv1.KeyVal("payload.tags.1", typesv1.ValStr("b")),
+					typesv1.KeyVal("other", typesv1.ValStr(`{"x":1}`)),
+				},
+			},
+		},
+		{
+			name:       "json object kept nested",
+			input:      `{"msg":"request","payload":"{\"user\":42,\"tags\":[\"a\"]}"}`,
+			expand:     []*FieldExpansion{{Field: "payload"}},
+			keepNested: true,
+			want: &typesv1.Log{
+				Body: "request",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("payload", typesv1.ValObj(
+						typesv1.KeyVal("user", typesv1.ValI64(42)),
+						typesv1.KeyVal("tags", typesv1.ValArr(typesv1.ValStr("a"))),
+					)),
+				},
+			},
+		},
+		{
+			name:   "json within json",
+			input:  `{"msg":"request","payload":"{\"inner\":\"[1,{\\\"a\\\":true}]\"}"}`,
+			expand: []*FieldExpansion{{Field: "payload"}},
+			want: &typesv1.Log{
+				Body: "request",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("payload.inner.0", typesv1.ValI64(1)),
+					typesv1.KeyVal("payload.inner.1.a", typesv1.ValBool(true)),
+				},
+			},
+		},
+		{
+			name:   "logfmt",
+			input:  `{"msg":"request","ctx":"user=42 path=/a"}`,
+			expand: []*FieldExpansion{{Field: "ctx"}},
+			want: &typesv1.Log{
+				Body: "request",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("ctx.user", typesv1.ValI64(42)),
+					typesv1.KeyVal("ctx.path", typesv1.ValStr("/a")),
+				},
+			},
+		},
+		{
+			name:   "text isn't logfmt",
+			input:  `{"msg":"request","ctx":"it went a=b fine"}`,
+			expand: []*FieldExpansion{{Field: "ctx"}},
+			want: &typesv1.Log{
+				Body: "request",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("ctx", typesv1.ValStr("it went a=b fine")),
+				},
+			},
+		},
+		{
+			name:   "url query",
+			input:  `{"msg":"request","query":"q=hello%20world&page=2&tag=a&tag=b"}`,
+			expand: []*FieldExpansion{{Field: "query"}},
+			want: &typesv1.Log{
+				Body: "request",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("query.q", typesv1.ValStr("hello world")),
+					typesv1.KeyVal("query.page", typesv1.ValStr("2")),
+					typesv1.KeyVal("query.tag.0", typesv1.ValStr("a")),
+					typesv1.KeyVal("query.tag.1", typesv1.ValStr("b")),
+				},
+			},
+		},
+		{
+			name:   "base64 json",
+			input:  `{"msg":"request","token":"eyJzdWIiOiJib2IifQ=="}`,
+			expand: []*FieldExpansion{{Field: "token", Formats: []string{ExpandBase64JSON}}},
+			want: &typesv1.Log{
+				Body: "request",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("token.sub", typesv1.ValStr("bob")),
+				},
+			},
+		},
+		{
+			name:   "only the formats asked for",
+			input:  `{"msg":"request","ctx":"user=42"}`,
+			expand: []*FieldExpansion{{Field: "ctx", Formats: []string{ExpandJSON}}},
+			want: &typesv1.Log{
+				Body: "request",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("ctx", typesv1.ValStr("user=42")),
+				},
+			},
+		},
+		{
+			name:   "json message",
+			input:  `{"msg":"{\"message\":\"login\",\"level\":\"warn\",\"user\":42}","service":"auth"}`,
+			expand: []*FieldExpansion{{Field: "msg"}},
+			want: &typesv1.Log{
+				Body:           "login",
+				SeverityText:   "warn",
+				SeverityNumber: 13,
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("service", typesv1.ValStr("auth")),
+					typesv1.KeyVal("user", typesv1.ValI64(42)),
+				},
+			},
+		},
+		{
+			name:   "logfmt message",
+			input:  `level=info msg="event=login user=42"`,
+			expand: []*FieldExpansion{{Field: "msg"}},
+			want: &typesv1.Log{
+				SeverityText:   "info",
+				SeverityNumber: 9,
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("event", typesv1.ValStr("login")),
+					typesv1.KeyVal("user", typesv1.ValI64(42)),
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.newULID = func() *typesv1.ULID { return nil }
+			opts.timeNow = func() time.Time { return now }
+			opts.Expand = tt.expand
+			opts.KeepNested = tt.keepNested
+
+			sink := bufsink.NewSizedBufferedSink(100, nil)
+			require.NoError(t, Scan(context.Background(), strings.NewReader(tt.input), sink, opts))
+
+			require.Len(t, sink.Buffered, 1)
+			tt.want.Raw = []byte(tt.input)
+			tt.want.ObservedTimestamp = timestamppb.New(now)
+			diff := cmp.Diff(tt.want, sink.Buffered[0], protocmp.Transform())
+			require.Empty(t, diff)
+		})
+	}
+}
+
+func TestNewFieldExpansion(t *testing.T) {
+	_, err := NewFieldExpansion("payload", []string{"json", "yaml"})
+	require.Error(t, err)
+	_, err = NewFieldExpansion("", nil)
+	require.Error(t, err)
+	exp, err := NewFieldExpansion("payload", []string{"json", "base64"})
+	require.NoError(t, err)
+	require.Equal(t, &FieldExpansion{Field: "payload", Formats: []string{"json", "base64"}}, exp)
+}
@@ -14,7 +14,7 @@ require (
 	github.com/fatih/color v1.18.0
 	github.com/go-logfmt/logfmt v0.6.1
 	github.com/google/go-cmp v0.7.0
-	github.com/kr/logfmt v0.0.0-20210122060352-19f9bcb100e6
+	github.com/klauspost/compress v1.18.0
 	github.com/lucasb-eyer/go-colorful v1.3.0
 	github.com/mattn/go-colorable v0.1.14
 	github.com/mattn/go-isatty v0.0.20
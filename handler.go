@@ -3,27 +3,28 @@ package humanlog
 import (
 	"time"
 
-	typesv1 "github.com/minitape/api/go/types/v1"
 	"github.com/humanlogio/humanlog/internal/pkg/config"
-	"github.com/kr/logfmt"
+	typesv1 "github.com/minitape/api/go/types/v1"
 	"github.com/oklog/ulid/v2"
 )
 
-// Handler can recognize it's log lines, parse them and prettify them.
-type Handler interface {
-	CanHandle(line []byte) bool
-	Prettify(skipUnchanged bool) []byte
-	logfmt.Handler
-}
-
 var DefaultOptions = func() *HandlerOptions {
 	opts := &HandlerOptions{
 		TimeFields: []string{"time", "ts", "@timestamp", "timestamp", "Timestamp", "asctime",
 			"stageTimestamp", "requestReceivedTimestamp", // for kubernetes audit logs
 		},
-		MessageFields: []string{"message", "msg", "Body"},
-		LevelFields:   []string{"level", "lvl", "loglevel", "severity", "SeverityText"},
-		timeNow:       time.Now,
+		MessageFields:    []string{"message", "msg", "Body"},
+		LevelFields:      []string{"level", "lvl", "loglevel", "severity", "SeverityText"},
+		TraceIDFields:    []string{"trace_id", "traceId", "traceID", "trace.id", "dd.trace_id", "otelTraceID", "TraceId"},
+		SpanIDFields:     []string{"span_id", "spanId", "spanID", "span.id", "dd.span_id", "otelSpanID", "SpanId"},
+		TraceFlagsFields: []string{"trace_flags", "traceFlags", "TraceFlags"},
+		DecimalIDFields:  []string{"dd.trace_id", "dd.span_id"},
+		TraceParentFields: []string{"traceparent", "traceParent", "X-Amzn-Trace-Id", "x-amzn-trace-id",
+			"http.request.header.traceparent", // as recorded by OTel instrumentations
+		},
+		TextFormats:      DefaultTextFormats(),
+		PrefixExtractors: DefaultPrefixExtractors(),
+		timeNow:          time.Now,
 		newULID: func() *typesv1.ULID {
 			u := ulid.Make()
 			return typesv1.ULIDFromBytes(nil, u)
@@ -38,9 +39,64 @@ type HandlerOptions struct {
 	LevelFields     []string
 	DetectTimestamp bool
 	DetectDuration  bool
+	// TraceIDFields and SpanIDFields hold the IDs of the trace and the
+	// span an event was emitted in, written in hex or in decimal.
+	TraceIDFields    []string
+	SpanIDFields     []string
+	TraceFlagsFields []string
+	// DecimalIDFields are the trace and span ID fields that are only
+	// ever written in decimal, like Datadog's, whose IDs could pass for
+	// hex when they have 16 digits.
+	DecimalIDFields []string
+	// TraceParentFields hold a whole trace context, as propagated in W3C
+	// `traceparent` or AWS X-Ray `X-Amzn-Trace-Id` headers.
+	TraceParentFields []string
+	// KeepNested keeps the objects and arrays of JSON lines as such,
+	// instead of flattening them into dotted keys like `a.b.0`.
+	KeepNested bool
+	// Patterns parse text lines in formats that aren't otherwise
+	// recognized. They're tried before the other handlers.
+	Patterns []*Pattern
+	// TextFormats are the layouts of logging libraries that print text.
+	// They're tried after JSON and syslog.
+	TextFormats []*Pattern
+	// Columns name the columns of CSV or TSV lines that have no header.
+	Columns []string
+	// ColumnDelimiter separates the columns of CSV or TSV lines. When
+	// it's 0, commas and tabs are tried.
+	ColumnDelimiter rune
+	// ExternalParsers parse lines with executables of their own. They're
+	// tried before the other handlers.
+	ExternalParsers []*ExternalParser
+	// Handlers pick the registered handlers that are tried on lines, in
+	// the order they're tried at first, like `json` or `logfmt`. All of
+	// them are tried, by priority, when nil.
+	Handlers []string
+	// DisabledHandlers are never tried. Patterns, text formats and
+	// external parsers can be disabled one by one, like `text:glog`.
+	DisabledHandlers []string
+	// PrefixExtractors strip prefixes, like docker-compose's, that are
+	// put in front of structured lines.
+	PrefixExtractors []*PrefixExtractor
+	// ResourcePromotions move the attributes that tell where events come
+	// from, like `hostname`, to their resource. The first promotion of a
+	// field applies. There are none by default, as promoted fields are
+	// renamed; DefaultResourcePromotions are the usual ones.
+	ResourcePromotions []*ResourcePromotion
+	// Severities map level names, in lowercase, to severity numbers,
+	// overriding the built-in mapping. Names are matched regardless of
+	// case.
+	Severities map[string]uint32
+	// Expand decodes the values of fields that are documents of their
+	// own, like JSON in a string, into attributes.
+	Expand []*FieldExpansion
+	// Multiline, when set, attaches continuation lines such as stack
+	// traces to the structured event preceding them.
+	Multiline *MultilineOptions
 
 	timeNow func() time.Time
 	newULID func() *typesv1.ULID
+	explain *explainer
 }
 
 var _ = func() *HandlerOptions {
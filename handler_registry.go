@@ -0,0 +1,209 @@
+package humanlog
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	typesv1 "github.com/minitape/api/go/types/v1"
+)
+
+// The priorities of the built-in handlers. Handlers are tried from the
+// highest priority to the lowest, so that a handler registered with a
+// priority between two of these is tried between them.
+const (
+	PriorityContainer      = 900
+	PriorityTable          = 800
+	PriorityPatterns       = 600
+	PriorityJSON           = 500
+	PrioritySyslog         = 490
+	PriorityTextFormats    = 400
+	PriorityLogfmt         = 300
+	PriorityPrefixJSON     = 200
+	PriorityPrefixLogfmt   = 190
+	PriorityZapDevelopment = 100
+)
+
+// TryHandleFunc parses `lineData` into `ev`, if it recognizes it.
+type TryHandleFunc func(lineData []byte, ev *typesv1.Log) bool
+
+// registeredHandler is a handler of the registry. Its handlers are made
+// anew for each handler chain, since handlers keep state.
+type registeredHandler struct {
+	name     string
+	priority int
+	new      func(opts *HandlerOptions) []namedHandler
+}
+
+// namedHandler is a handler of a chain, named for `Explainer`.
+type namedHandler struct {
+	name string
+	try  TryHandleFunc
+	// tryPart is set instead of `try` by handlers that take lines that
+	// aren't whole events, like headers, telling if `ev` is complete.
+	tryPart func(lineData []byte, ev *typesv1.Log) (handled, complete bool)
+	// pinned handlers stay ahead of the handlers that recognize lines,
+	// as the lines they take look like the lines of others.
+	pinned bool
+}
+
+func (h namedHandler) handle(lineData []byte, ev *typesv1.Log) (handled, complete bool) {
+	if h.tryPart != nil {
+		return h.tryPart(lineData, ev)
+	}
+	return h.try(lineData, ev), true
+}
+
+var handlerRegistry struct {
+	sync.Mutex
+	handlers []*registeredHandler
+}
+
+func init() {
+	register := func(name string, priority int, new func(opts *HandlerOptions) []namedHandler) {
+		handlerRegistry.handlers = append(handlerRegistry.handlers, &registeredHandler{name: name, priority: priority, new: new})
+	}
+	register("container", PriorityContainer, func(opts *HandlerOptions) []namedHandler {
+		// the lines within envelopes are parsed by the other handlers
+		within := *opts
+		within.DisabledHandlers = append(slices.Clone(opts.DisabledHandlers), "container", "table")
+		containerLogs := &containerLogHandler{handle: newHandlerChain(&within)}
+		return []namedHandler{{name: "container", pinned: true, tryPart: func(lineData []byte, data *typesv1.Log) (bool, bool) {
+			opts.explain.enter("container")
+			defer opts.explain.leave()
+			return containerLogs.TryHandle(lineData, data)
+		}}}
+	})
+	register("table", PriorityTable, func(opts *HandlerOptions) []namedHandler {
+		tables := newTableHandler(opts)
+		return []namedHandler{{name: "table", pinned: true, tryPart: tables.TryHandle}}
+	})
+	register("external", PriorityExternal, externalHandlers)
+	register("patterns", PriorityPatterns, func(opts *HandlerOptions) []namedHandler {
+		var handlers []namedHandler
+		for _, pattern := range opts.Patterns {
+			handlers = append(handlers, namedHandler{name: "pattern:" + pattern.Name, try: func(lineData []byte, data *typesv1.Log) bool {
+				return pattern.TryHandle(lineData, data, opts)
+			}})
+		}
+		return handlers
+	})
+	register("json", PriorityJSON, func(opts *HandlerOptions) []namedHandler {
+		jsonEntry := &JSONHandler{Opts: opts}
+		return []namedHandler{{name: "json", try: jsonEntry.TryHandle}}
+	})
+	register("syslog", PrioritySyslog, func(opts *HandlerOptions) []namedHandler {
+		jsonEntry, logfmtEntry := &JSONHandler{Opts: opts}, &LogfmtHandler{Opts: opts}
+		return []namedHandler{{name: "syslog", try: func(lineData []byte, data *typesv1.Log) bool {
+			return trySyslog(lineData, data, opts, jsonEntry, logfmtEntry)
+		}}}
+	})
+	register("text", PriorityTextFormats, func(opts *HandlerOptions) []namedHandler {
+		var handlers []namedHandler
+		for _, format := range opts.TextFormats {
+			handlers = append(handlers, namedHandler{name: "text:" + format.Name, try: func(lineData []byte, data *typesv1.Log) bool {
+				return format.TryHandle(lineData, data, opts)
+			}})
+		}
+		return handlers
+	})
+	register("logfmt", PriorityLogfmt, func(opts *HandlerOptions) []namedHandler {
+		logfmtEntry := &LogfmtHandler{Opts: opts}
+		return []namedHandler{{name: "logfmt", try: logfmtEntry.TryHandle}}
+	})
+	register("prefix+json", PriorityPrefixJSON, func(opts *HandlerOptions) []namedHandler {
+		jsonEntry := &JSONHandler{Opts: opts}
+		return []namedHandler{{name: "prefix+json", try: func(lineData []byte, data *typesv1.Log) bool {
+			return tryPrefixExtractors(lineData, data, opts.PrefixExtractors, jsonEntry)
+		}}}
+	})
+	register("prefix+logfmt", PriorityPrefixLogfmt, func(opts *HandlerOptions) []namedHandler {
+		logfmtEntry := &LogfmtHandler{Opts: opts}
+		return []namedHandler{{name: "prefix+logfmt", try: func(lineData []byte, data *typesv1.Log) bool {
+			return tryPrefixExtractors(lineData, data, opts.PrefixExtractors, logfmtEntry)
+		}}}
+	})
+	register("zap-dev", PriorityZapDevelopment, func(opts *HandlerOptions) []namedHandler {
+		jsonEntry := &JSONHandler{Opts: opts}
+		return []namedHandler{{name: "zap-dev", try: func(lineData []byte, data *typesv1.Log) bool {
+			return tryZapDevPrefix(lineData, data, jsonEntry)
+		}}}
+	})
+}
+
+// RegisterHandler adds a handler of custom formats to the handlers tried
+// on lines, before those of a lower priority. Handlers of the same
+// priority are tried in the order they're registered, after the
+// built-in ones. It panics if a handler of that name is registered
+// already.
+//
+// Like the built-in handlers, `tryHandle` must only change `ev` when it
+// recognizes the line.
+func RegisterHandler(name string, priority int, tryHandle TryHandleFunc) {
+	handlerRegistry.Lock()
+	defer handlerRegistry.Unlock()
+	if name == "" {
+		panic("humanlog: RegisterHandler with no name")
+	}
+	for _, h := range handlerRegistry.handlers {
+		if h.name == name {
+			panic(fmt.Sprintf("humanlog: RegisterHandler called twice for handler %q", name))
+		}
+	}
+	handlerRegistry.handlers = append(handlerRegistry.handlers, &registeredHandler{
+		name:     name,
+		priority: priority,
+		new: func(*HandlerOptions) []namedHandler {
+			return []namedHandler{{name: name, try: tryHandle}}
+		},
+	})
+}
+
+// RegisteredHandlers names the registered handlers, in the order they're
+// tried by default.
+func RegisteredHandlers() []string {
+	var names []string
+	for _, h := range registeredHandlers() {
+		names = append(names, h.name)
+	}
+	return names
+}
+
+func registeredHandlers() []*registeredHandler {
+	handlerRegistry.Lock()
+	defer handlerRegistry.Unlock()
+	handlers := slices.Clone(handlerRegistry.handlers)
+	slices.SortStableFunc(handlers, func(a, b *registeredHandler) int {
+		return b.priority - a.priority
+	})
+	return handlers
+}
+
+// chainHandlers makes the handlers of a chain, as picked and ordered by
+// the `Handlers` option, less those of `DisabledHandlers`.
+func chainHandlers(opts *HandlerOptions) []namedHandler {
+	registered := registeredHandlers()
+	picked := registered
+	if opts.Handlers != nil {
+		picked = nil
+		for _, name := range opts.Handlers {
+			i := slices.IndexFunc(registered, func(h *registeredHandler) bool { return h.name == name })
+			if i >= 0 && !slices.Contains(picked, registered[i]) {
+				picked = append(picked, registered[i])
+			}
+		}
+	}
+	var handlers []namedHandler
+	for _, h := range picked {
+		if slices.Contains(opts.DisabledHandlers, h.name) {
+			continue
+		}
+		for _, handler := range h.new(opts) {
+			// the patterns and text formats can be disabled one by one
+			if !slices.Contains(opts.DisabledHandlers, handler.name) {
+				handlers = append(handlers, handler)
+			}
+		}
+	}
+	return handlers
+}
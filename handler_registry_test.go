@@ -0,0 +1,88 @@
+package humanlog
+
+import (
+	"bytes"
+	"testing"
+
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerRegistry(t *testing.T) {
+	// only recognizes lines like `ACME|level|message`
+	RegisterHandler("test-acme", PriorityJSON+1, func(lineData []byte, ev *typesv1.Log) bool {
+		rest, ok := bytes.CutPrefix(lineData, []byte("ACME|"))
+		if !ok {
+			return false
+		}
+		level, msg, ok := bytes.Cut(rest, []byte("|"))
+		if !ok {
+			return false
+		}
+		ev.SeverityText, ev.Body = string(level), string(msg)
+		return true
+	})
+	require.Panics(t, func() { RegisterHandler("test-acme", 0, nil) })
+	require.Panics(t, func() { RegisterHandler("json", 0, nil) })
+	require.Equal(t, []string{"container", "table", "external", "patterns", "test-acme", "json", "syslog", "text", "logfmt", "prefix+json", "prefix+logfmt", "zap-dev"}, RegisteredHandlers())
+
+	tests := []struct {
+		name        string
+		handlers    []string
+		disabled    []string
+		input       string
+		wantHandler string
+		wantBody    string
+	}{
+		{
+			name:        "registered handler",
+			input:       "ACME|warn|disk full",
+			wantHandler: "test-acme",
+			wantBody:    "disk full",
+		},
+		{
+			name:        "registered handler disabled",
+			input:       "ACME|warn|disk full",
+			disabled:    []string{"test-acme"},
+			wantHandler: "",
+		},
+		{
+			name:        "picked and ordered",
+			input:       `level=info msg=hello`,
+			handlers:    []string{"logfmt", "json"},
+			wantHandler: "logfmt",
+			wantBody:    "hello",
+		},
+		{
+			name:        "not picked",
+			input:       `level=info msg=hello`,
+			handlers:    []string{"json"},
+			wantHandler: "",
+		},
+		{
+			name:        "container disabled",
+			input:       `{"log":"hello\n","stream":"stdout","time":"2024-10-11T15:25:06Z"}`,
+			disabled:    []string{"container"},
+			wantHandler: "json",
+		},
+		{
+			name:        "single text format disabled",
+			input:       `I0102 15:04:05.123456   12345 main.go:42] hello`,
+			disabled:    []string{"text:glog"},
+			wantHandler: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Handlers = tt.handlers
+			opts.DisabledHandlers = tt.disabled
+
+			got := NewExplainer(opts).Explain([]byte(tt.input))
+			require.Equal(t, tt.wantHandler, got.Handler)
+			if tt.wantBody != "" {
+				require.Equal(t, tt.wantBody, got.Log.Body)
+			}
+		})
+	}
+}
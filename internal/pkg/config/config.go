@@ -9,8 +9,8 @@ import (
 	"strings"
 	"time"
 
-	typesv1 "github.com/minitape/api/go/types/v1"
 	"github.com/humanlogio/humanlog/pkg/sink/stdiosink"
+	typesv1 "github.com/minitape/api/go/types/v1"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 )
@@ -106,10 +106,15 @@ func ReadConfigFile(path string, dflt *Config, writebackIfMigrated bool) (*Confi
 		return dflt, nil
 	}
 
+	configFile, extensions, err := splitParserExtensions(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("decoding config file: %v", err)
+	}
 	cfg := Config{CurrentConfig: new(CurrentConfig)}
 	if err := protojson.Unmarshal(configFile, &cfg); err != nil {
 		return nil, fmt.Errorf("decoding config file: %v", err)
 	}
+	cfg.ParserExtensions = extensions
 	cfg.path = path
 	if cfg.migrated && writebackIfMigrated {
 		if err := cfg.WriteBack(); err != nil {
@@ -126,6 +131,10 @@ func WriteConfigFile(path string, config *Config) error {
 	if err != nil {
 		return fmt.Errorf("marshaling config file: %v", err)
 	}
+	content, err = joinParserExtensions(content, config.ParserExtensions)
+	if err != nil {
+		return fmt.Errorf("marshaling config file: %v", err)
+	}
 
 	newf, err := os.CreateTemp(filepath.Dir(path), "humanlog_configfile")
 	if err != nil {
@@ -159,6 +168,10 @@ func WriteConfigFile(path string, config *Config) error {
 type Config struct {
 	Version int `json:"version"`
 	*CurrentConfig
+	// ParserExtensions configure the parser too. They live in the
+	// `parser` section of the config file, but aren't part of
+	// `CurrentConfig` yet.
+	ParserExtensions *ParserExtensions `json:"-"`
 	// unexported, the filepath where the `Config` get's serialized and saved to
 	path     string
 	migrated bool
@@ -228,7 +241,10 @@ func (cfg Config) populateEmpty(other *Config) *Config {
 	if other == nil {
 		return &cfg
 	}
-	out := &Config{Version: cfg.Version, path: cfg.path}
+	out := &Config{Version: cfg.Version, path: cfg.path, ParserExtensions: cfg.ParserExtensions}
+	if out.ParserExtensions == nil {
+		out.ParserExtensions = other.ParserExtensions
+	}
 	if out.CurrentConfig == nil {
 		out.CurrentConfig = new(typesv1.LocalhostConfig)
 	}
@@ -239,6 +255,211 @@ func (cfg Config) populateEmpty(other *Config) *Config {
 	return out
 }
 
+// ParserExtensions configure the parser beyond what `CurrentConfig` can
+// express. They're found next to the rest of the `parser` section of the
+// config file.
+type ParserExtensions struct {
+	// Patterns are the user defined text formats, in `parser.patterns`.
+	Patterns []*ParsePattern `json:"patterns,omitempty"`
+	// Definitions are sub-patterns that patterns can refer to, in
+	// addition to the built-in ones.
+	Definitions map[string]string `json:"patternDefinitions,omitempty"`
+	// Expand names the fields whose values are documents of their own,
+	// in `parser.expand`.
+	Expand []*ParseExpansion `json:"expand,omitempty"`
+	// Severities map level names to OpenTelemetry severity numbers,
+	// written as numbers or by their short names like `INFO2`, in
+	// `parser.severities`.
+	Severities map[string]string `json:"severities,omitempty"`
+	// TraceContext names the fields that hold trace contexts, in
+	// addition to the usual ones, in `parser.traceContext`.
+	TraceContext *ParseTraceContext `json:"traceContext,omitempty"`
+	// ResourceAttributes move fields to the resource of events, before
+	// the usual ones do, in `parser.resourceAttributes`.
+	ResourceAttributes []*ParseResourceAttribute `json:"resourceAttributes,omitempty"`
+	// PromoteResources moves the fields humanlog knows to be about
+	// resources, like `hostname`, to the resource of events, after
+	// ResourceAttributes, in `parser.promoteResources`.
+	PromoteResources *bool `json:"promoteResources,omitempty"`
+	// Handlers pick the handlers tried on lines and their order, in
+	// `parser.handlers`.
+	Handlers []string `json:"handlers,omitempty"`
+	// DisabledHandlers are never tried, in `parser.disabledHandlers`.
+	DisabledHandlers []string `json:"disabledHandlers,omitempty"`
+	// Prefixes are the prefixes put in front of structured lines, like
+	// docker-compose's, in `parser.prefixes`. A prefix named like a
+	// built-in one replaces it.
+	Prefixes []*ParsePrefix `json:"prefixes,omitempty"`
+	// DisabledPrefixes name the built-in prefixes to leave alone, like
+	// `stern`, in `parser.disabledPrefixes`.
+	DisabledPrefixes []string `json:"disabledPrefixes,omitempty"`
+	// Multiline attaches stack traces and other continuation lines to the
+	// event that precedes them, in `parser.multiline`.
+	Multiline *ParseMultiline `json:"multiline,omitempty"`
+	// External are executables that parse lines, in `parser.external`.
+	External []*ParseExternal `json:"external,omitempty"`
+	// Columns name the columns of CSV or TSV lines that have no header,
+	// in `parser.columns`.
+	Columns []string `json:"columns,omitempty"`
+	// ColumnDelimiter is the character that separates the columns of
+	// CSV or TSV lines, in `parser.columnDelimiter`. Commas and tabs
+	// are tried when it's empty.
+	ColumnDelimiter string `json:"columnDelimiter,omitempty"`
+}
+
+type ParsePattern struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	// TimeLayout is the Go layout of the timestamp, if the usual ones
+	// don't fit.
+	TimeLayout string `json:"timeLayout,omitempty"`
+}
+
+type ParseExpansion struct {
+	Field string `json:"field"`
+	// Formats are the encodings to try: `json`, `logfmt`, `query` or
+	// `base64`. All of them are tried when empty.
+	Formats []string `json:"formats,omitempty"`
+}
+
+type ParseTraceContext struct {
+	TraceIDFields    []string `json:"traceIdFields,omitempty"`
+	SpanIDFields     []string `json:"spanIdFields,omitempty"`
+	TraceFlagsFields []string `json:"traceFlagsFields,omitempty"`
+	// DecimalIDFields are ID fields only ever written in decimal.
+	DecimalIDFields []string `json:"decimalIdFields,omitempty"`
+	// TraceParentFields hold W3C `traceparent` or AWS X-Ray
+	// `X-Amzn-Trace-Id` headers.
+	TraceParentFields []string `json:"traceParentFields,omitempty"`
+}
+
+type ParseResourceAttribute struct {
+	Field string `json:"field"`
+	// As is the resource attribute the field becomes, the field itself
+	// when empty. `-` keeps the field as an attribute.
+	As string `json:"as,omitempty"`
+}
+
+type ParsePrefix struct {
+	Name string `json:"name"`
+	// Pattern matches prefixed lines, the line that was prefixed in its
+	// `rest_of_line` group.
+	Pattern string `json:"pattern"`
+	// Attributes and ResourceAttributes map the named groups of the
+	// pattern to the attributes they're recorded as. Groups that aren't
+	// mapped are recorded as attributes named after them.
+	Attributes         map[string]string `json:"attributes,omitempty"`
+	ResourceAttributes map[string]string `json:"resourceAttributes,omitempty"`
+}
+
+type ParseMultiline struct {
+	// Patterns match continuation lines, in addition to the usual ones.
+	Patterns []string `json:"patterns,omitempty"`
+	// Key is the attribute continuation lines are kept under.
+	Key      string `json:"key,omitempty"`
+	MaxLines int    `json:"maxLines,omitempty"`
+	// FlushAfter is how long an event waits for continuation lines,
+	// like `500ms`.
+	FlushAfter string `json:"flushAfter,omitempty"`
+}
+
+type ParseExternal struct {
+	Name string `json:"name"`
+	// Command is the executable and its arguments.
+	Command []string `json:"command"`
+	// Prefix and Match pick the lines given to the executable, by their
+	// start or with a regular expression.
+	Prefix string `json:"prefix,omitempty"`
+	Match  string `json:"match,omitempty"`
+	// Encoding is how the executable replies, `protojson` or
+	// `delimited`. It's `protojson` when empty.
+	Encoding string `json:"encoding,omitempty"`
+	// Timeout bounds how long replies take, like `2s`.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// parserExtensionKeys are the keys of the `parser` section that hold
+// `ParserExtensions`, named like its fields.
+var parserExtensionKeys = []string{"patterns", "patternDefinitions", "expand", "severities", "traceContext", "resourceAttributes", "promoteResources", "handlers", "disabledHandlers", "prefixes", "disabledPrefixes", "multiline", "external", "columns", "columnDelimiter"}
+
+// splitParserExtensions takes the parser extensions out of a config file,
+// so that the rest can be decoded as a `CurrentConfig`.
+func splitParserExtensions(p []byte) ([]byte, *ParserExtensions, error) {
+	var (
+		top    map[string]json.RawMessage
+		parser map[string]json.RawMessage
+	)
+	if err := json.Unmarshal(p, &top); err != nil || top["parser"] == nil {
+		// not for us to complain about
+		return p, nil, nil
+	}
+	if err := json.Unmarshal(top["parser"], &parser); err != nil {
+		return p, nil, nil
+	}
+	extensions := make(map[string]json.RawMessage)
+	for _, key := range parserExtensionKeys {
+		if raw, ok := parser[key]; ok {
+			extensions[key] = raw
+			delete(parser, key)
+		}
+	}
+	if len(extensions) == 0 {
+		return p, nil, nil
+	}
+	out := new(ParserExtensions)
+	for key, raw := range extensions {
+		// one at a time, to tell which one is wrong
+		if err := json.Unmarshal([]byte(`{"`+key+`":`+string(raw)+`}`), out); err != nil {
+			return nil, nil, fmt.Errorf("parser.%s: %v", key, err)
+		}
+	}
+	var err error
+	if top["parser"], err = json.Marshal(parser); err != nil {
+		return nil, nil, err
+	}
+	if p, err = json.Marshal(top); err != nil {
+		return nil, nil, err
+	}
+	return p, out, nil
+}
+
+// joinParserExtensions puts the parser extensions back into an encoded
+// `CurrentConfig`.
+func joinParserExtensions(p []byte, extensions *ParserExtensions) ([]byte, error) {
+	if extensions == nil {
+		return p, nil
+	}
+	var (
+		top    map[string]json.RawMessage
+		parser map[string]json.RawMessage
+		fields map[string]json.RawMessage
+	)
+	if err := json.Unmarshal(p, &top); err != nil {
+		return nil, err
+	}
+	if raw, ok := top["parser"]; ok {
+		if err := json.Unmarshal(raw, &parser); err != nil {
+			return nil, err
+		}
+	} else {
+		parser = make(map[string]json.RawMessage)
+	}
+	raw, err := json.Marshal(extensions)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range fields {
+		parser[key] = value
+	}
+	if top["parser"], err = json.Marshal(parser); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(top, "", "\t")
+}
+
 func mergeLocalhostConfig(prev, next *typesv1.LocalhostConfig) *typesv1.LocalhostConfig {
 	out := proto.Clone(prev).(*typesv1.LocalhostConfig)
 	if out == nil {
@@ -308,6 +529,9 @@ func mergeParser(prev, next *typesv1.ParseConfig) *typesv1.ParseConfig {
 	if next.Level != nil {
 		out.Level = mergeParseLevel(prev.GetLevel(), next.Level)
 	}
+	if next.Kv != nil {
+		out.Kv = proto.Clone(next.Kv).(*typesv1.ParseConfig_KV)
+	}
 	return out
 }
 
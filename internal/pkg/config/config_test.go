@@ -2,6 +2,8 @@ package config
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -78,6 +80,31 @@ func TestConfig_populateEmpty(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "respect update to kv detection",
+			input: Config{
+				CurrentConfig: &typesv1.LocalhostConfig{
+					Parser: &typesv1.ParseConfig{
+						Kv: &typesv1.ParseConfig_KV{DetectDuration: true},
+					},
+				},
+			},
+			defaultCfg: &Config{
+				CurrentConfig: &typesv1.LocalhostConfig{
+					Parser: &typesv1.ParseConfig{
+						Message: &typesv1.ParseConfig_Message{FieldNames: []string{"msg"}},
+					},
+				},
+			},
+			want: &Config{
+				CurrentConfig: &typesv1.LocalhostConfig{
+					Parser: &typesv1.ParseConfig{
+						Message: &typesv1.ParseConfig_Message{FieldNames: []string{"msg"}},
+						Kv:      &typesv1.ParseConfig_KV{DetectDuration: true},
+					},
+				},
+			},
+		},
 		{
 			name: "respect update to api client settings",
 			input: Config{
@@ -122,3 +149,84 @@ func TestConfig_populateEmpty(t *testing.T) {
 		})
 	}
 }
+
+func TestParserExtensions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{
+	"version": 2,
+	"parser": {
+		"message": {"fieldNames": ["msg"]},
+		"patterns": [
+			{"name": "mine", "pattern": "%{MYLEVEL:level} %{GREEDYDATA:msg}", "timeLayout": "15:04"}
+		],
+		"patternDefinitions": {"MYLEVEL": "[IWE]"},
+		"expand": [{"field": "payload", "formats": ["json", "base64"]}, {"field": "msg"}],
+		"severities": {"audit": "INFO2", "boom": "24"},
+		"traceContext": {"traceIdFields": ["tid"], "traceParentFields": ["propagation"], "decimalIdFields": ["tid"]},
+		"resourceAttributes": [{"field": "dc", "as": "cloud.region"}, {"field": "env", "as": "-"}],
+		"promoteResources": true,
+		"handlers": ["logfmt", "json"],
+		"disabledHandlers": ["text:glog"],
+		"prefixes": [{"name": "stern", "pattern": "^(?P<pod>\\S+) (?P<rest_of_line>.*)$", "resourceAttributes": {"pod": "k8s.pod.name"}}],
+		"disabledPrefixes": ["kubectl"],
+		"multiline": {"patterns": ["^\\s*\\|"], "maxLines": 50, "flushAfter": "1s"},
+		"external": [{"name": "acme", "command": ["acme-parser", "-v"], "prefix": "ACME ", "encoding": "delimited", "timeout": "2s"}],
+		"columns": ["time", "level", "message"],
+		"columnDelimiter": ";"
+	}
+}`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	want := &ParserExtensions{
+		Patterns: []*ParsePattern{
+			{Name: "mine", Pattern: "%{MYLEVEL:level} %{GREEDYDATA:msg}", TimeLayout: "15:04"},
+		},
+		Definitions: map[string]string{"MYLEVEL": "[IWE]"},
+		Expand: []*ParseExpansion{
+			{Field: "payload", Formats: []string{"json", "base64"}},
+			{Field: "msg"},
+		},
+		Severities: map[string]string{"audit": "INFO2", "boom": "24"},
+		TraceContext: &ParseTraceContext{
+			TraceIDFields:     []string{"tid"},
+			TraceParentFields: []string{"propagation"},
+			DecimalIDFields:   []string{"tid"},
+		},
+		ResourceAttributes: []*ParseResourceAttribute{
+			{Field: "dc", As: "cloud.region"},
+			{Field: "env", As: "-"},
+		},
+		PromoteResources: ptr(true),
+		Handlers:         []string{"logfmt", "json"},
+		DisabledHandlers: []string{"text:glog"},
+		Prefixes: []*ParsePrefix{
+			{
+				Name:               "stern",
+				Pattern:            `^(?P<pod>\S+) (?P<rest_of_line>.*)$`,
+				ResourceAttributes: map[string]string{"pod": "k8s.pod.name"},
+			},
+		},
+		DisabledPrefixes: []string{"kubectl"},
+		Multiline: &ParseMultiline{
+			Patterns:   []string{`^\s*\|`},
+			MaxLines:   50,
+			FlushAfter: "1s",
+		},
+		External: []*ParseExternal{
+			{Name: "acme", Command: []string{"acme-parser", "-v"}, Prefix: "ACME ", Encoding: "delimited", Timeout: "2s"},
+		},
+		Columns:         []string{"time", "level", "message"},
+		ColumnDelimiter: ";",
+	}
+
+	cfg, err := ReadConfigFile(path, nil, false)
+	require.NoError(t, err)
+	require.Equal(t, want, cfg.ParserExtensions)
+	require.Equal(t, []string{"msg"}, cfg.Parser.GetMessage().GetFieldNames())
+
+	require.NoError(t, WriteConfigFile(path, cfg))
+	cfg, err = ReadConfigFile(path, nil, false)
+	require.NoError(t, err)
+	require.Equal(t, want, cfg.ParserExtensions)
+	require.Equal(t, []string{"msg"}, cfg.Parser.GetMessage().GetFieldNames())
+}
@@ -0,0 +1,134 @@
+package follow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// DefaultPollInterval is how often a followed file is checked for new
+// data, rotation or truncation once its end has been reached.
+const DefaultPollInterval = 250 * time.Millisecond
+
+// Reader reads a file and keeps on reading it after reaching its end, in
+// the way `tail -f` does. It only returns io.EOF once its context is done.
+//
+// A Reader always notices when its file gets truncated (including by a
+// logrotate `copytruncate`) and starts over from the beginning. When it
+// follows by name, it also notices when the file is renamed and a new
+// one created in its place, and switches to the new file once it's done
+// reading the old one (like `tail -F`). Following by name, the file
+// needn't exist yet, the Reader waits for it to be created.
+type Reader struct {
+	ctx    context.Context
+	path   string
+	byName bool
+	poll   time.Duration
+
+	// f is nil until the file exists, when following by name
+	f      *os.File
+	offset int64
+}
+
+var _ io.ReadCloser = (*Reader)(nil)
+
+// Open starts following the file at `path`.
+func Open(ctx context.Context, path string, byName bool, poll time.Duration) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil && !(byName && errors.Is(err, os.ErrNotExist)) {
+		return nil, err
+	}
+	if poll <= 0 {
+		poll = DefaultPollInterval
+	}
+	return &Reader{ctx: ctx, path: path, byName: byName, poll: poll, f: f}, nil
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	for r.f == nil {
+		f, err := os.Open(r.path)
+		switch {
+		case err == nil:
+			r.f = f
+		case !errors.Is(err, os.ErrNotExist):
+			return 0, err
+		default:
+			select {
+			case <-r.ctx.Done():
+				return 0, io.EOF
+			case <-time.After(r.poll):
+			}
+		}
+	}
+	for {
+		n, err := r.f.Read(p)
+		r.offset += int64(n)
+		if n > 0 {
+			return n, nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, err
+		}
+		// reached the end, see if the file changed under our feet
+		changed, err := r.checkFile()
+		if err != nil {
+			return 0, err
+		}
+		if changed {
+			continue
+		}
+		select {
+		case <-r.ctx.Done():
+			return 0, io.EOF
+		case <-time.After(r.poll):
+		}
+	}
+}
+
+// checkFile detects truncations and rotations of the followed file, and
+// repositions the reader accordingly. It reports whether it did so.
+func (r *Reader) checkFile() (bool, error) {
+	fi, err := r.f.Stat()
+	if err != nil {
+		return false, fmt.Errorf("stating followed file %q: %v", r.path, err)
+	}
+	if fi.Size() < r.offset {
+		// truncated, start over
+		if _, err := r.f.Seek(0, io.SeekStart); err != nil {
+			return false, fmt.Errorf("seeking to start of truncated file %q: %v", r.path, err)
+		}
+		r.offset = 0
+		return true, nil
+	}
+	if !r.byName {
+		return false, nil
+	}
+	pfi, err := os.Stat(r.path)
+	if err != nil {
+		// the file is gone, probably in the middle of a rotation; it
+		// should come back eventually
+		return false, nil
+	}
+	if os.SameFile(fi, pfi) {
+		return false, nil
+	}
+	// rotated, and we've read everything there was in the old file
+	f, err := os.Open(r.path)
+	if err != nil {
+		return false, nil
+	}
+	_ = r.f.Close()
+	r.f = f
+	r.offset = 0
+	return true, nil
+}
+
+func (r *Reader) Close() error {
+	if r.f == nil {
+		return nil
+	}
+	return r.f.Close()
+}
@@ -0,0 +1,94 @@
+package follow
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReaderFollowsTruncationAndRotation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("one\n"), 0644))
+
+	r, err := Open(ctx, path, true, 10*time.Millisecond)
+	require.NoError(t, err)
+	defer r.Close()
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		sc := bufio.NewScanner(r)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+	}()
+	next := func() string {
+		t.Helper()
+		select {
+		case line := <-lines:
+			return line
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for a line")
+			return ""
+		}
+	}
+	appendTo := func(path, content string) {
+		t.Helper()
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+		require.NoError(t, err)
+		_, err = f.WriteString(content)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+
+	require.Equal(t, "one", next())
+
+	appendTo(path, "two\n")
+	require.Equal(t, "two", next())
+
+	// copytruncate
+	require.NoError(t, os.Truncate(path, 0))
+	time.Sleep(50 * time.Millisecond)
+	appendTo(path, "three\n")
+	require.Equal(t, "three", next())
+
+	// rename+create
+	require.NoError(t, os.Rename(path, path+".1"))
+	appendTo(path+".1", "four\n")
+	require.Equal(t, "four", next())
+	appendTo(path, "five\n")
+	require.Equal(t, "five", next())
+
+	cancel()
+	for range lines {
+	}
+}
+
+func TestReaderWaitsForFile(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	_, err := Open(ctx, path, false, 10*time.Millisecond)
+	require.Error(t, err)
+
+	r, err := Open(ctx, path, true, 10*time.Millisecond)
+	require.NoError(t, err)
+	defer r.Close()
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = os.WriteFile(path, []byte("one\n"), 0644)
+	}()
+	line, err := bufio.NewReader(r).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "one\n", line)
+}
@@ -0,0 +1,115 @@
+package otlpreceiver
+
+import (
+	"time"
+
+	typesv1 "github.com/minitape/api/go/types/v1"
+	otlpcommon "go.opentelemetry.io/proto/otlp/common/v1"
+	otlplogs "go.opentelemetry.io/proto/otlp/logs/v1"
+	otlpresource "go.opentelemetry.io/proto/otlp/resource/v1"
+	otlptrace "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func resourceFromOTLP(schemaURL string, in *otlpresource.Resource) *typesv1.Resource {
+	return typesv1.NewResource(schemaURL, typesv1.FromOTLPKVs(in.GetAttributes()))
+}
+
+func scopeFromOTLP(schemaURL string, in *otlpcommon.InstrumentationScope) *typesv1.Scope {
+	return typesv1.NewScope(schemaURL, in.GetName(), in.GetVersion(), typesv1.FromOTLPKVs(in.GetAttributes()))
+}
+
+func timeFromOTLP(unixNano uint64) *timestamppb.Timestamp {
+	if unixNano == 0 {
+		return nil
+	}
+	return timestamppb.New(time.Unix(0, int64(unixNano)))
+}
+
+// isZeroID tells if a trace or span ID is absent, which OTLP represents
+// as either empty or all zeroes.
+func isZeroID(id []byte) bool {
+	for _, b := range id {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func logFromOTLP(resource *typesv1.Resource, scope *typesv1.Scope, in *otlplogs.LogRecord) *typesv1.Log {
+	out := &typesv1.Log{
+		Timestamp:         timeFromOTLP(in.TimeUnixNano),
+		ObservedTimestamp: timeFromOTLP(in.ObservedTimeUnixNano),
+		TraceFlags:        in.Flags,
+		SeverityText:      in.SeverityText,
+		SeverityNumber:    uint32(in.SeverityNumber),
+		ServiceName:       resource.LookupServiceName(),
+		Resource:          resource,
+		Scope:             scope,
+		Attributes:        typesv1.FromOTLPKVs(in.Attributes),
+	}
+	if out.Timestamp == nil {
+		out.Timestamp = out.ObservedTimestamp
+	}
+	if !isZeroID(in.TraceId) {
+		out.TraceId = typesv1.TraceIDFromBytesSlice(nil, in.TraceId)
+	}
+	if !isZeroID(in.SpanId) {
+		out.SpanId = typesv1.SpanIDFromBytesSlice(nil, in.SpanId)
+	}
+	switch body := in.Body.GetValue().(type) {
+	case nil:
+	case *otlpcommon.AnyValue_StringValue:
+		out.Body = body.StringValue
+	default:
+		// structured bodies don't fit in a message, keep them
+		// around as an attribute instead
+		out.Attributes = append(out.Attributes, typesv1.KeyVal("body", typesv1.FromOTLPVal(in.Body)))
+	}
+	return out
+}
+
+func spanFromOTLP(resource *typesv1.Resource, scope *typesv1.Scope, in *otlptrace.Span) *typesv1.Span {
+	out := &typesv1.Span{
+		TraceId:     typesv1.TraceIDFromBytesSlice(nil, in.TraceId),
+		SpanId:      typesv1.SpanIDFromBytesSlice(nil, in.SpanId),
+		TraceState:  in.TraceState,
+		Flags:       in.Flags,
+		Name:        in.Name,
+		Kind:        typesv1.Span_SpanKind(in.Kind),
+		ServiceName: resource.LookupServiceName(),
+		Time:        timeFromOTLP(in.StartTimeUnixNano),
+		Resource:    resource,
+		Scope:       scope,
+		Attributes:  typesv1.FromOTLPKVs(in.Attributes),
+	}
+	if !isZeroID(in.ParentSpanId) {
+		out.ParentSpanId = typesv1.SpanIDFromBytesSlice(nil, in.ParentSpanId)
+	}
+	if in.EndTimeUnixNano >= in.StartTimeUnixNano {
+		out.Duration = durationpb.New(time.Duration(in.EndTimeUnixNano - in.StartTimeUnixNano))
+	}
+	for _, ev := range in.Events {
+		out.Events = append(out.Events, &typesv1.Span_Event{
+			Timestamp: timeFromOTLP(ev.TimeUnixNano),
+			Name:      ev.Name,
+			Kvs:       typesv1.FromOTLPKVs(ev.Attributes),
+		})
+	}
+	for _, link := range in.Links {
+		out.Links = append(out.Links, &typesv1.Span_Link{
+			TraceId:    typesv1.TraceIDFromBytesSlice(nil, link.TraceId),
+			SpanId:     typesv1.SpanIDFromBytesSlice(nil, link.SpanId),
+			TraceState: link.TraceState,
+			Kvs:        typesv1.FromOTLPKVs(link.Attributes),
+			Flags:      link.Flags,
+		})
+	}
+	out.Status = &typesv1.Span_Status{
+		Message: in.Status.GetMessage(),
+		Code:    typesv1.Span_Status_Code(in.Status.GetCode()),
+	}
+	return out
+}
@@ -0,0 +1,154 @@
+package otlpreceiver
+
+import (
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+
+	collogpb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	contentTypeProtobuf = "application/x-protobuf"
+	contentTypeJSON     = "application/json"
+
+	maxRequestSize = 32 << 20
+)
+
+// HTTPHandler serves the OTLP/HTTP endpoints, `/v1/logs` and
+// `/v1/traces`, accepting both binary protobuf and JSON payloads.
+func (rcv *Receiver) HTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/v1/logs", rcv.httpExport(
+		func() proto.Message { return new(collogpb.ExportLogsServiceRequest) },
+		func(req *http.Request, msg proto.Message) (proto.Message, error) {
+			export := msg.(*collogpb.ExportLogsServiceRequest)
+			return &collogpb.ExportLogsServiceResponse{}, rcv.ExportLogs(req.Context(), export)
+		},
+	))
+	mux.Handle("/v1/traces", rcv.httpExport(
+		func() proto.Message { return new(coltracepb.ExportTraceServiceRequest) },
+		func(req *http.Request, msg proto.Message) (proto.Message, error) {
+			export := msg.(*coltracepb.ExportTraceServiceRequest)
+			return &coltracepb.ExportTraceServiceResponse{}, rcv.ExportTraces(req.Context(), export)
+		},
+	))
+	return mux
+}
+
+func (rcv *Receiver) httpExport(
+	newMsg func() proto.Message,
+	export func(*http.Request, proto.Message) (proto.Message, error),
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		contentType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if contentType != contentTypeProtobuf && contentType != contentTypeJSON {
+			http.Error(w, "unsupported content type, use "+contentTypeProtobuf+" or "+contentTypeJSON, http.StatusUnsupportedMediaType)
+			return
+		}
+
+		var body io.Reader = http.MaxBytesReader(w, req.Body, maxRequestSize)
+		if req.Header.Get("Content-Encoding") == "gzip" {
+			gzr, err := gzip.NewReader(body)
+			if err != nil {
+				http.Error(w, "invalid gzip body: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			defer gzr.Close()
+			body = gzr
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			http.Error(w, "reading body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		msg := newMsg()
+		if contentType == contentTypeJSON {
+			err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, msg)
+			fixJSONIDs(msg)
+		} else {
+			err = proto.Unmarshal(data, msg)
+		}
+		if err != nil {
+			http.Error(w, "invalid payload: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		res, err := export(req, msg)
+		if err != nil {
+			rcv.ll.ErrorContext(req.Context(), "couldn't receive export", slog.String("path", req.URL.Path), slog.Any("err", err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var out []byte
+		if contentType == contentTypeJSON {
+			out, err = protojson.Marshal(res)
+		} else {
+			out, err = proto.Marshal(res)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(out)
+	}
+}
+
+// fixJSONIDs undoes what protojson did to trace and span IDs. OTLP/JSON
+// encodes them in hex, while protojson decodes `bytes` fields as base64.
+func fixJSONIDs(msg proto.Message) {
+	switch msg := msg.(type) {
+	case *collogpb.ExportLogsServiceRequest:
+		for _, rl := range msg.ResourceLogs {
+			for _, sl := range rl.ScopeLogs {
+				for _, rec := range sl.LogRecords {
+					rec.TraceId = hexID(rec.TraceId, 16)
+					rec.SpanId = hexID(rec.SpanId, 8)
+				}
+			}
+		}
+	case *coltracepb.ExportTraceServiceRequest:
+		for _, rs := range msg.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, span := range ss.Spans {
+					span.TraceId = hexID(span.TraceId, 16)
+					span.SpanId = hexID(span.SpanId, 8)
+					span.ParentSpanId = hexID(span.ParentSpanId, 8)
+					for _, link := range span.Links {
+						link.TraceId = hexID(link.TraceId, 16)
+						link.SpanId = hexID(link.SpanId, 8)
+					}
+				}
+			}
+		}
+	}
+}
+
+// hexID recovers an ID of `size` bytes from its hex representation that
+// was mistakenly decoded as base64. Hex digits are all valid base64, so
+// encoding back gives the original string.
+func hexID(id []byte, size int) []byte {
+	if len(id) != size*2*3/4 {
+		return id
+	}
+	raw, err := hex.DecodeString(base64.StdEncoding.EncodeToString(id))
+	if err != nil {
+		return id
+	}
+	return raw
+}
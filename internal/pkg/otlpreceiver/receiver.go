@@ -0,0 +1,162 @@
+// Package otlpreceiver accepts logs and traces sent by OpenTelemetry
+// exporters, over gRPC or HTTP, and hands them to humanlog's sinks.
+package otlpreceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"github.com/humanlogio/humanlog/pkg/sink"
+	"github.com/oklog/ulid/v2"
+	collogpb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	DefaultGRPCPort = 4317
+	DefaultHTTPPort = 4318
+)
+
+// Receiver implements the OTLP logs and trace services. Sinks are only
+// ever called one at a time.
+type Receiver struct {
+	ll    *slog.Logger
+	logs  sink.Sink
+	spans sink.SpanSink
+
+	mu sync.Mutex
+
+	timeNow func() time.Time
+	newULID func() *typesv1.ULID
+}
+
+func NewReceiver(ll *slog.Logger, logs sink.Sink, spans sink.SpanSink) *Receiver {
+	return &Receiver{
+		ll:      ll,
+		logs:    logs,
+		spans:   spans,
+		timeNow: time.Now,
+		newULID: func() *typesv1.ULID {
+			return typesv1.ULIDFromBytes(nil, ulid.Make())
+		},
+	}
+}
+
+// RegisterGRPC exposes the receiver's services on `srv`.
+func (rcv *Receiver) RegisterGRPC(srv *grpc.Server) {
+	collogpb.RegisterLogsServiceServer(srv, &logsService{rcv: rcv})
+	coltracepb.RegisterTraceServiceServer(srv, &traceService{rcv: rcv})
+}
+
+func (rcv *Receiver) ExportLogs(ctx context.Context, req *collogpb.ExportLogsServiceRequest) error {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	for _, rl := range req.ResourceLogs {
+		resource := resourceFromOTLP(rl.SchemaUrl, rl.Resource)
+		for _, sl := range rl.ScopeLogs {
+			scope := scopeFromOTLP(sl.SchemaUrl, sl.Scope)
+			for _, rec := range sl.LogRecords {
+				ev := logFromOTLP(resource, scope, rec)
+				ev.Ulid = rcv.newULID()
+				if ev.ObservedTimestamp == nil {
+					ev.ObservedTimestamp = timestamppb.New(rcv.timeNow())
+				}
+				if err := rcv.logs.Receive(ctx, ev); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (rcv *Receiver) ExportTraces(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) error {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	for _, rs := range req.ResourceSpans {
+		resource := resourceFromOTLP(rs.SchemaUrl, rs.Resource)
+		for _, ss := range rs.ScopeSpans {
+			scope := scopeFromOTLP(ss.SchemaUrl, ss.Scope)
+			for _, span := range ss.Spans {
+				sp := spanFromOTLP(resource, scope, span)
+				sp.Ulid = rcv.newULID()
+				if err := rcv.spans.ReceiveSpan(ctx, sp); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type logsService struct {
+	collogpb.UnimplementedLogsServiceServer
+	rcv *Receiver
+}
+
+func (svc *logsService) Export(ctx context.Context, req *collogpb.ExportLogsServiceRequest) (*collogpb.ExportLogsServiceResponse, error) {
+	if err := svc.rcv.ExportLogs(ctx, req); err != nil {
+		svc.rcv.ll.ErrorContext(ctx, "couldn't receive logs", slog.Any("err", err))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &collogpb.ExportLogsServiceResponse{}, nil
+}
+
+type traceService struct {
+	coltracepb.UnimplementedTraceServiceServer
+	rcv *Receiver
+}
+
+func (svc *traceService) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	if err := svc.rcv.ExportTraces(ctx, req); err != nil {
+		svc.rcv.ll.ErrorContext(ctx, "couldn't receive spans", slog.Any("err", err))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+// Serve listens for OTLP/gRPC on `grpcAddr` and OTLP/HTTP on `httpAddr`
+// until `ctx` is done or either server fails.
+func (rcv *Receiver) Serve(ctx context.Context, grpcAddr, httpAddr string) error {
+	grpcL, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		return fmt.Errorf("listening for OTLP/gRPC: %v", err)
+	}
+	httpL, err := net.Listen("tcp", httpAddr)
+	if err != nil {
+		_ = grpcL.Close()
+		return fmt.Errorf("listening for OTLP/HTTP: %v", err)
+	}
+
+	grpcSrv := grpc.NewServer()
+	rcv.RegisterGRPC(grpcSrv)
+	httpSrv := &http.Server{Handler: rcv.HTTPHandler()}
+
+	errc := make(chan error, 2)
+	go func() { errc <- grpcSrv.Serve(grpcL) }()
+	go func() { errc <- httpSrv.Serve(httpL) }()
+
+	select {
+	case <-ctx.Done():
+	case err = <-errc:
+	}
+	grpcSrv.GracefulStop()
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second)
+	defer cancel()
+	_ = httpSrv.Shutdown(shutdownCtx)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
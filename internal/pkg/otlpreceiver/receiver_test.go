@@ -0,0 +1,187 @@
+package otlpreceiver
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"github.com/humanlogio/humanlog/pkg/sink/bufsink"
+	collogpb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	otlpcommon "go.opentelemetry.io/proto/otlp/common/v1"
+	otlplogs "go.opentelemetry.io/proto/otlp/logs/v1"
+	otlpresource "go.opentelemetry.io/proto/otlp/resource/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type spanBuffer struct {
+	spans []*typesv1.Span
+}
+
+func (buf *spanBuffer) ReceiveSpan(ctx context.Context, span *typesv1.Span) error {
+	buf.spans = append(buf.spans, span)
+	return nil
+}
+
+func newTestReceiver() (*Receiver, *bufsink.SizedBuffer, *spanBuffer) {
+	logs := bufsink.NewSizedBufferedSink(100, nil)
+	spans := new(spanBuffer)
+	rcv := NewReceiver(slog.New(slog.DiscardHandler), logs, spans)
+	rcv.newULID = func() *typesv1.ULID { return nil }
+	return rcv, logs, spans
+}
+
+var (
+	traceID = []byte{0x5b, 0x8e, 0xff, 0xf7, 0x98, 0x03, 0x81, 0x03, 0xd2, 0x69, 0xb6, 0x33, 0x81, 0x3f, 0xc6, 0x0c}
+	spanID  = []byte{0xee, 0xe1, 0x9b, 0x7e, 0xc3, 0xc1, 0xb1, 0x74}
+	ts      = time.Date(2024, 10, 11, 15, 25, 6, 0, time.UTC)
+)
+
+func TestHTTPLogs(t *testing.T) {
+	resource := typesv1.NewResource("", []*typesv1.KV{typesv1.KeyVal("service.name", typesv1.ValStr("api"))})
+	scope := typesv1.NewScope("", "app", "1.0", nil)
+	want := &typesv1.Log{
+		ObservedTimestamp: timestamppb.New(ts),
+		Timestamp:         timestamppb.New(ts),
+		TraceId:           typesv1.TraceIDFromBytesSlice(nil, traceID),
+		SpanId:            typesv1.SpanIDFromBytesSlice(nil, spanID),
+		SeverityText:      "INFO",
+		SeverityNumber:    9,
+		ServiceName:       "api",
+		Body:              "hello",
+		Resource:          resource,
+		Scope:             scope,
+		Attributes:        []*typesv1.KV{typesv1.KeyVal("k", typesv1.ValI64(3))},
+	}
+
+	protoReq, err := proto.Marshal(&collogpb.ExportLogsServiceRequest{ResourceLogs: []*otlplogs.ResourceLogs{{
+		Resource: &otlpresource.Resource{Attributes: []*otlpcommon.KeyValue{
+			{Key: "service.name", Value: &otlpcommon.AnyValue{Value: &otlpcommon.AnyValue_StringValue{StringValue: "api"}}},
+		}},
+		ScopeLogs: []*otlplogs.ScopeLogs{{
+			Scope: &otlpcommon.InstrumentationScope{Name: "app", Version: "1.0"},
+			LogRecords: []*otlplogs.LogRecord{{
+				TimeUnixNano:         uint64(ts.UnixNano()),
+				ObservedTimeUnixNano: uint64(ts.UnixNano()),
+				SeverityNumber:       otlplogs.SeverityNumber_SEVERITY_NUMBER_INFO,
+				SeverityText:         "INFO",
+				Body:                 &otlpcommon.AnyValue{Value: &otlpcommon.AnyValue_StringValue{StringValue: "hello"}},
+				TraceId:              traceID,
+				SpanId:               spanID,
+				Attributes: []*otlpcommon.KeyValue{
+					{Key: "k", Value: &otlpcommon.AnyValue{Value: &otlpcommon.AnyValue_IntValue{IntValue: 3}}},
+				},
+			}},
+		}},
+	}}})
+	require.NoError(t, err)
+
+	jsonReq := []byte(`{"resourceLogs":[{
+		"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"api"}}]},
+		"scopeLogs":[{
+			"scope":{"name":"app","version":"1.0"},
+			"logRecords":[{
+				"timeUnixNano":"1728660306000000000",
+				"observedTimeUnixNano":"1728660306000000000",
+				"severityNumber":9,
+				"severityText":"INFO",
+				"body":{"stringValue":"hello"},
+				"traceId":"5b8efff798038103d269b633813fc60c",
+				"spanId":"eee19b7ec3c1b174",
+				"attributes":[{"key":"k","value":{"intValue":"3"}}],
+				"someFutureField":true
+			}]
+		}]
+	}]}`)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+	}{
+		{name: "protobuf", contentType: "application/x-protobuf", body: protoReq},
+		{name: "json", contentType: "application/json", body: jsonReq},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rcv, logs, _ := newTestReceiver()
+			srv := httptest.NewServer(rcv.HTTPHandler())
+			defer srv.Close()
+
+			res, err := http.Post(srv.URL+"/v1/logs", tt.contentType, bytes.NewReader(tt.body))
+			require.NoError(t, err)
+			defer res.Body.Close()
+			require.Equal(t, http.StatusOK, res.StatusCode)
+			require.Equal(t, tt.contentType, res.Header.Get("Content-Type"))
+
+			require.Len(t, logs.Buffered, 1)
+			diff := cmp.Diff(want, logs.Buffered[0], protocmp.Transform())
+			require.Empty(t, diff)
+		})
+	}
+}
+
+func TestHTTPTraces(t *testing.T) {
+	rcv, _, spans := newTestReceiver()
+	srv := httptest.NewServer(rcv.HTTPHandler())
+	defer srv.Close()
+
+	body := `{"resourceSpans":[{"scopeSpans":[{"spans":[{
+		"traceId":"5b8efff798038103d269b633813fc60c",
+		"spanId":"eee19b7ec3c1b174",
+		"name":"GET /",
+		"kind":2,
+		"startTimeUnixNano":"1728660306000000000",
+		"endTimeUnixNano":"1728660306250000000",
+		"status":{"code":2,"message":"boom"}
+	}]}]}]}`
+	res, err := http.Post(srv.URL+"/v1/traces", "application/json", bytes.NewReader([]byte(body)))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	want := &typesv1.Span{
+		TraceId:  typesv1.TraceIDFromBytesSlice(nil, traceID),
+		SpanId:   typesv1.SpanIDFromBytesSlice(nil, spanID),
+		Name:     "GET /",
+		Kind:     typesv1.Span_SERVER,
+		Time:     timestamppb.New(ts),
+		Duration: durationpb.New(250 * time.Millisecond),
+		Resource: typesv1.NewResource("", nil),
+		Scope:    typesv1.NewScope("", "", "", nil),
+		Status:   &typesv1.Span_Status{Code: typesv1.Span_Status_ERROR, Message: "boom"},
+	}
+	require.Len(t, spans.spans, 1)
+	diff := cmp.Diff(want, spans.spans[0], protocmp.Transform())
+	require.Empty(t, diff)
+}
+
+func TestHTTPRejects(t *testing.T) {
+	rcv, _, _ := newTestReceiver()
+	srv := httptest.NewServer(rcv.HTTPHandler())
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/v1/logs")
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+
+	res, err = http.Post(srv.URL+"/v1/logs", "text/plain", bytes.NewReader(nil))
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusUnsupportedMediaType, res.StatusCode)
+
+	res, err = http.Post(srv.URL+"/v1/logs", "application/json", bytes.NewReader([]byte("{")))
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
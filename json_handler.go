@@ -160,7 +160,7 @@ func (h *JSONHandler) UnmarshalJSON(data []byte) bool {
 		OnFloat: func(prefixes flatjson.Prefixes, val flatjson.Float) {
 			key := keyFor(data, prefixes, val.Name)
 			if !hasFoundTimestamp {
-				hasFoundTimestamp = checkEachUntilFound(h.Opts.TimeFields, func(s string) bool {
+				hasFoundTimestamp = checkEachUntilFound(h.Opts, "TimeFields", h.Opts.TimeFields, func(s string) bool {
 					if !fieldsEqualAllString(s, key) {
 						return false
 					}
@@ -172,7 +172,7 @@ func (h *JSONHandler) UnmarshalJSON(data []byte) bool {
 				}
 			}
 			if !hasFoundLevel {
-				hasFoundLevel = checkEachUntilFound(h.Opts.LevelFields, func(s string) bool {
+				hasFoundLevel = checkEachUntilFound(h.Opts, "LevelFields", h.Opts.LevelFields, func(s string) bool {
 					if !fieldsEqualAllString(s, key) {
 						return false
 					}
@@ -183,12 +183,18 @@ func (h *JSONHandler) UnmarshalJSON(data []byte) bool {
 					return
 				}
 			}
-			h.Fields = append(h.Fields, typesv1.KeyVal(key, typesv1.ValF64(val.Value)))
+			if h.Opts.DetectDuration {
+				if unit, ok := durationUnitOf(key); ok {
+					h.addField(data, prefixes, val.Name, key, typesv1.ValDuration(time.Duration(val.Value*float64(unit))))
+					return
+				}
+			}
+			h.addField(data, prefixes, val.Name, key, typesv1.ValF64(val.Value))
 		},
 		OnInteger: func(prefixes flatjson.Prefixes, val flatjson.Integer) {
 			key := keyFor(data, prefixes, val.Name)
 			if !hasFoundTimestamp {
-				hasFoundTimestamp = checkEachUntilFound(h.Opts.TimeFields, func(s string) bool {
+				hasFoundTimestamp = checkEachUntilFound(h.Opts, "TimeFields", h.Opts.TimeFields, func(s string) bool {
 					if !fieldsEqualAllString(s, key) {
 						return false
 					}
@@ -201,7 +207,7 @@ func (h *JSONHandler) UnmarshalJSON(data []byte) bool {
 				}
 			}
 			if !hasFoundLevel {
-				hasFoundLevel = checkEachUntilFound(h.Opts.LevelFields, func(s string) bool {
+				hasFoundLevel = checkEachUntilFound(h.Opts, "LevelFields", h.Opts.LevelFields, func(s string) bool {
 					if !fieldsEqualAllString(s, key) {
 						return false
 					}
@@ -213,7 +219,13 @@ func (h *JSONHandler) UnmarshalJSON(data []byte) bool {
 					return
 				}
 			}
-			h.Fields = append(h.Fields, typesv1.KeyVal(key, typesv1.ValI64(val.Value)))
+			if h.Opts.DetectDuration {
+				if unit, ok := durationUnitOf(key); ok {
+					h.addField(data, prefixes, val.Name, key, typesv1.ValDuration(time.Duration(val.Value)*unit))
+					return
+				}
+			}
+			h.addField(data, prefixes, val.Name, key, typesv1.ValI64(val.Value))
 		},
 		OnString: func(prefixes flatjson.Prefixes, val flatjson.String) {
 			key := keyFor(data, prefixes, val.Name)
@@ -223,7 +235,7 @@ func (h *JSONHandler) UnmarshalJSON(data []byte) bool {
 					// it might be a weird timestamp in an array (`asctime`)
 				}
 
-				hasFoundTimestamp = checkEachUntilFound(h.Opts.TimeFields, func(s string) bool {
+				hasFoundTimestamp = checkEachUntilFound(h.Opts, "TimeFields", h.Opts.TimeFields, func(s string) bool {
 					// HACK: `asctime` is a weird format...
 					if s == "asctime" && len(prefixes) == 1 && val.Name.IsArrayIndex() && val.Name.Index() == 0 {
 						// it might be a weird timestamp in an array (`asctime`)
@@ -241,7 +253,7 @@ func (h *JSONHandler) UnmarshalJSON(data []byte) bool {
 				}
 			}
 			if !hasFoundLevel {
-				hasFoundLevel = checkEachUntilFound(h.Opts.LevelFields, func(s string) bool {
+				hasFoundLevel = checkEachUntilFound(h.Opts, "LevelFields", h.Opts.LevelFields, func(s string) bool {
 					if !fieldsEqualAllString(s, key) {
 						return false
 					}
@@ -253,7 +265,7 @@ func (h *JSONHandler) UnmarshalJSON(data []byte) bool {
 				}
 			}
 			if !hasFoundMsg {
-				hasFoundMsg = checkEachUntilFound(h.Opts.MessageFields, func(s string) bool {
+				hasFoundMsg = checkEachUntilFound(h.Opts, "MessageFields", h.Opts.MessageFields, func(s string) bool {
 					if !fieldsEqualAllString(s, key) {
 						return false
 					}
@@ -277,27 +289,133 @@ func (h *JSONHandler) UnmarshalJSON(data []byte) bool {
 				}
 				ts, ok := tryParseTime(value)
 				if ok {
-					h.Fields = append(h.Fields, typesv1.KeyVal(key, typesv1.ValTime(ts)))
+					h.addField(data, prefixes, val.Name, key, typesv1.ValTime(ts))
 					return
 				}
 			}
-			h.Fields = append(h.Fields, typesv1.KeyVal(key, typesv1.ValStr(value)))
+			if h.Opts.DetectDuration {
+				if d, ok := tryParseDurationString(value); ok {
+					h.addField(data, prefixes, val.Name, key, typesv1.ValDuration(d))
+					return
+				}
+			}
+			h.addField(data, prefixes, val.Name, key, typesv1.ValStr(value))
 		},
 		OnBoolean: func(prefixes flatjson.Prefixes, val flatjson.Bool) {
 			key := keyFor(data, prefixes, val.Name)
-			h.Fields = append(h.Fields, typesv1.KeyVal(key, typesv1.ValBool(val.Value)))
+			h.addField(data, prefixes, val.Name, key, typesv1.ValBool(val.Value))
 		},
 		OnNull: func(prefixes flatjson.Prefixes, val flatjson.Null) {
 			key := keyFor(data, prefixes, val.Name)
-			h.Fields = append(h.Fields, typesv1.KeyVal(key, typesv1.ValNull()))
+			h.addField(data, prefixes, val.Name, key, typesv1.ValNull())
 		},
 	})
 	if err != nil {
 		return false
 	}
+	if h.Opts.KeepNested {
+		for _, kv := range h.Fields {
+			retype(kv.Value)
+		}
+	}
 	return ok
 }
 
+// addField records a field of the line. Fields of nested objects and
+// arrays are flattened into dotted keys, unless the options keep them
+// nested.
+func (h *JSONHandler) addField(data []byte, prefixes flatjson.Prefixes, name flatjson.Prefix, key string, val *typesv1.Val) {
+	if !h.Opts.KeepNested || len(prefixes) == 0 {
+		h.Fields = append(h.Fields, typesv1.KeyVal(key, val))
+		return
+	}
+	path := append(prefixes[:len(prefixes):len(prefixes)], name)
+	h.Fields = insertKV(data, h.Fields, path, val)
+}
+
+// insertKV inserts `val` in `kvs` at `path`, whose first element is an
+// object key. Values are scanned in the order they appear in the
+// document, so what `path` leads to, if it exists, is always last.
+func insertKV(data []byte, kvs []*typesv1.KV, path flatjson.Prefixes, val *typesv1.Val) []*typesv1.KV {
+	key := prefixKey(data, path[0])
+	if len(path) == 1 {
+		return append(kvs, typesv1.KeyVal(key, val))
+	}
+	var parent *typesv1.Val
+	if n := len(kvs); n > 0 && kvs[n-1].Key == key {
+		parent = kvs[n-1].Value
+	} else {
+		parent = newContainer(path[1])
+		kvs = append(kvs, typesv1.KeyVal(key, parent))
+	}
+	insertInto(data, parent, path[1:], val)
+	return kvs
+}
+
+// insertItem is insertKV for arrays, the first element of `path` being
+// an index.
+func insertItem(data []byte, items []*typesv1.Val, path flatjson.Prefixes, val *typesv1.Val) []*typesv1.Val {
+	if len(path) == 1 {
+		return append(items, val)
+	}
+	var parent *typesv1.Val
+	if i := path[0].Index(); i < len(items) {
+		parent = items[i]
+	} else {
+		parent = newContainer(path[1])
+		items = append(items, parent)
+	}
+	insertInto(data, parent, path[1:], val)
+	return items
+}
+
+func insertInto(data []byte, parent *typesv1.Val, path flatjson.Prefixes, val *typesv1.Val) {
+	switch {
+	case parent.GetObj() != nil:
+		obj := parent.GetObj()
+		obj.Kvs = insertKV(data, obj.Kvs, path, val)
+	case parent.GetArr() != nil:
+		arr := parent.GetArr()
+		arr.Items = insertItem(data, arr.Items, path, val)
+	}
+}
+
+// newContainer makes the object or the array that `child` is a key or
+// an index of.
+func newContainer(child flatjson.Prefix) *typesv1.Val {
+	if child.IsArrayIndex() {
+		return typesv1.ValArr()
+	}
+	return typesv1.ValObj()
+}
+
+// retype sets the types of the objects and arrays that were built by
+// inserting into them, once they're complete.
+func retype(v *typesv1.Val) {
+	switch {
+	case v.GetObj() != nil:
+		kvs := v.GetObj().Kvs
+		for _, kv := range kvs {
+			retype(kv.Value)
+		}
+		v.Type = typesv1.ValObj(kvs...).Type
+	case v.GetArr() != nil:
+		items := v.GetArr().Items
+		for _, item := range items {
+			retype(item)
+		}
+		v.Type = typesv1.ValArr(items...).Type
+	}
+}
+
+func prefixKey(data []byte, pfx flatjson.Prefix) string {
+	key, err := flatjson.Unquote(pfx.Bytes(data))
+	if err != nil {
+		return pfx.String(data)
+	}
+	return string(key)
+}
+
 func keyFor(data []byte, prefixes flatjson.Prefixes, pfx flatjson.Prefix) string {
 	if len(prefixes) == 0 {
 		return flatjson.Prefixes{pfx}.AsString(data)
@@ -322,7 +440,7 @@ func convertBunyanLogLevelF64(level float64) string {
 	case 60:
 		return "fatal"
 	default:
-		return "???"
+		return strconv.FormatFloat(level, 'f', -1, 64)
 	}
 }
 
@@ -343,6 +461,6 @@ func convertBunyanLogLevelI64(level int64) string {
 	case 60:
 		return "fatal"
 	default:
-		return "???"
+		return strconv.FormatInt(level, 10)
 	}
 }
@@ -276,3 +276,118 @@ func TestParseKvTime(t *testing.T) {
 		})
 	}
 }
+
+func TestParseKvDuration(t *testing.T) {
+	tests := []struct {
+		name           string
+		raw            []byte
+		detectDuration bool
+		want           *typesv1.Val
+	}{
+		{
+			name:           "go duration",
+			raw:            []byte(`{"took": "1.5s"}`),
+			detectDuration: true,
+			want:           typesv1.ValDuration(1500 * time.Millisecond),
+		},
+		{
+			name:           "iso 8601 duration",
+			raw:            []byte(`{"took": "PT1M30S"}`),
+			detectDuration: true,
+			want:           typesv1.ValDuration(90 * time.Second),
+		},
+		{
+			name:           "integer in milliseconds",
+			raw:            []byte(`{"duration_ms": 250}`),
+			detectDuration: true,
+			want:           typesv1.ValDuration(250 * time.Millisecond),
+		},
+		{
+			name:           "float in nanoseconds",
+			raw:            []byte(`{"req": {"elapsed_ns": 1500.0}}`),
+			detectDuration: true,
+			want:           typesv1.ValDuration(1500 * time.Nanosecond),
+		},
+		{
+			name:           "camel case",
+			raw:            []byte(`{"latencyMs": 3}`),
+			detectDuration: true,
+			want:           typesv1.ValDuration(3 * time.Millisecond),
+		},
+		{
+			name:           "number without a unit",
+			raw:            []byte(`{"items": 250}`),
+			detectDuration: true,
+			want:           typesv1.ValI64(250),
+		},
+		{
+			name: "not detected",
+			raw:  []byte(`{"took": "1.5s"}`),
+			want: typesv1.ValStr("1.5s"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.DetectDuration = test.detectDuration
+			h := JSONHandler{Opts: opts}
+			ev := new(typesv1.Log)
+			if !h.TryHandle(test.raw, ev) {
+				t.Fatalf("failed to handle log")
+			}
+			got := ev.Attributes[0].Value
+			require.Empty(t, cmp.Diff(test.want, got, protocmp.Transform()))
+		})
+	}
+}
+
+func TestJsonHandler_TryHandle_KeepNested(t *testing.T) {
+	raw := []byte(`{"msg":"hi","req":{"method":"GET","headers":{"accept":"*/*"},"ids":[1,2.5],"matrix":[[true],[null,"x"]],"objs":[{"a":1},{"a":2,"b":"c"}]},"n":3}`)
+
+	opts := DefaultOptions()
+	opts.KeepNested = true
+	h := JSONHandler{Opts: opts}
+	ev := new(typesv1.Log)
+	require.True(t, h.TryHandle(raw, ev))
+
+	want := []*typesv1.KV{
+		typesv1.KeyVal("req", typesv1.ValObj(
+			typesv1.KeyVal("method", typesv1.ValStr("GET")),
+			typesv1.KeyVal("headers", typesv1.ValObj(
+				typesv1.KeyVal("accept", typesv1.ValStr("*/*")),
+			)),
+			typesv1.KeyVal("ids", typesv1.ValArr(typesv1.ValI64(1), typesv1.ValF64(2.5))),
+			typesv1.KeyVal("matrix", typesv1.ValArr(
+				typesv1.ValArr(typesv1.ValBool(true)),
+				typesv1.ValArr(typesv1.ValNull(), typesv1.ValStr("x")),
+			)),
+			typesv1.KeyVal("objs", typesv1.ValArr(
+				typesv1.ValObj(typesv1.KeyVal("a", typesv1.ValI64(1))),
+				typesv1.ValObj(typesv1.KeyVal("a", typesv1.ValI64(2)), typesv1.KeyVal("b", typesv1.ValStr("c"))),
+			)),
+		)),
+		typesv1.KeyVal("n", typesv1.ValI64(3)),
+	}
+	require.Equal(t, "hi", ev.Body)
+	require.Empty(t, cmp.Diff(want, ev.Attributes, protocmp.Transform()))
+}
+
+func TestJsonHandler_TryHandle_KeepNestedPromotesNestedFields(t *testing.T) {
+	raw := []byte(`{"data":{"message":"hi","user":"bob"}}`)
+
+	opts := DefaultOptions()
+	opts.KeepNested = true
+	opts.MessageFields = append(opts.MessageFields, "data.message")
+	h := JSONHandler{Opts: opts}
+	ev := new(typesv1.Log)
+	require.True(t, h.TryHandle(raw, ev))
+
+	want := []*typesv1.KV{
+		typesv1.KeyVal("data", typesv1.ValObj(
+			typesv1.KeyVal("user", typesv1.ValStr("bob")),
+		)),
+	}
+	require.Equal(t, "hi", ev.Body)
+	require.Empty(t, cmp.Diff(want, ev.Attributes, protocmp.Transform()))
+}
@@ -2,6 +2,9 @@ package humanlog
 
 import (
 	"bytes"
+	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-logfmt/logfmt"
@@ -54,7 +57,7 @@ func (h *LogfmtHandler) UnmarshalLogfmt(data []byte) bool {
 			key := string(dec.Key())
 			val := string(dec.Value())
 			if h.Time.IsZero() {
-				foundTime := checkEachUntilFound(h.Opts.TimeFields, func(field string) bool {
+				foundTime := checkEachUntilFound(h.Opts, "TimeFields", h.Opts.TimeFields, func(field string) bool {
 					if !fieldsEqualAllString(key, field) {
 						return false
 					}
@@ -70,7 +73,7 @@ func (h *LogfmtHandler) UnmarshalLogfmt(data []byte) bool {
 			}
 
 			if len(h.Message) == 0 {
-				foundMessage := checkEachUntilFound(h.Opts.MessageFields, func(field string) bool {
+				foundMessage := checkEachUntilFound(h.Opts, "MessageFields", h.Opts.MessageFields, func(field string) bool {
 					if !fieldsEqualAllString(key, field) {
 						return false
 					}
@@ -83,7 +86,7 @@ func (h *LogfmtHandler) UnmarshalLogfmt(data []byte) bool {
 			}
 
 			if len(h.Level) == 0 {
-				foundLevel := checkEachUntilFound(h.Opts.LevelFields, func(field string) bool {
+				foundLevel := checkEachUntilFound(h.Opts, "LevelFields", h.Opts.LevelFields, func(field string) bool {
 					if !fieldsEqualAllString(key, field) {
 						return false
 					}
@@ -94,8 +97,102 @@ func (h *LogfmtHandler) UnmarshalLogfmt(data []byte) bool {
 					continue next_kv
 				}
 			}
-			h.Fields = append(h.Fields, typesv1.KeyVal(key, typesv1.ValStr(val)))
+			h.Fields = append(h.Fields, typesv1.KeyVal(key, h.typedValue(key, val)))
 		}
 	}
 	return dec.Err() == nil
 }
+
+// typedValue infers the type of a logfmt value, which is always written
+// as text, so that it's typed like it would be in JSON.
+func (h *LogfmtHandler) typedValue(key, val string) *typesv1.Val {
+	switch val {
+	case "true":
+		return typesv1.ValBool(true)
+	case "false":
+		return typesv1.ValBool(false)
+	}
+	if looksNumeric(val) {
+		i, err := strconv.ParseInt(val, 10, 64)
+		if err == nil {
+			if h.Opts.DetectDuration {
+				if unit, ok := durationUnitOf(key); ok {
+					return typesv1.ValDuration(time.Duration(i) * unit)
+				}
+			}
+			return typesv1.ValI64(i)
+		}
+		if errors.Is(err, strconv.ErrRange) {
+			// too big for an int64, and a float would lose digits
+			return typesv1.ValStr(val)
+		}
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			if h.Opts.DetectDuration {
+				if unit, ok := durationUnitOf(key); ok {
+					return typesv1.ValDuration(time.Duration(f * float64(unit)))
+				}
+			}
+			if roundTrips(f, val) {
+				return typesv1.ValF64(f)
+			}
+		}
+	}
+	if h.Opts.DetectDuration {
+		if d, ok := tryParseDurationString(val); ok {
+			return typesv1.ValDuration(d)
+		}
+	}
+	if h.Opts.DetectTimestamp {
+		for _, layout := range TimeFormats {
+			if ts, err := time.Parse(layout, val); err == nil {
+				return typesv1.ValTime(ts)
+			}
+		}
+	}
+	return typesv1.ValStr(val)
+}
+
+// roundTrips tells if `f` prints back as `s`, in the notation `s` is
+// written in. Values that don't, like `1.10` or ones with more digits
+// than a float holds, are left as text to be shown as they were logged.
+func roundTrips(f float64, s string) bool {
+	if !strings.ContainsAny(s, "eE") {
+		return strconv.FormatFloat(f, 'f', -1, 64) == s
+	}
+	mant, exp, _ := strings.Cut(strings.ToLower(s), "e")
+	fmant, fexp, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	return mant == fmant && trimExponent(exp) == trimExponent(fexp)
+}
+
+func trimExponent(exp string) string {
+	sign := ""
+	if rest, ok := strings.CutPrefix(exp, "-"); ok {
+		sign, exp = "-", rest
+	}
+	exp = strings.TrimLeft(strings.TrimPrefix(exp, "+"), "0")
+	if exp == "" {
+		return "0"
+	}
+	return sign + exp
+}
+
+// looksNumeric tells if `s` is written like a JSON number would be.
+// Numbers with leading zeros are left alone, as they're usually
+// identifiers that would lose their zeros.
+func looksNumeric(s string) bool {
+	digits := strings.TrimPrefix(s, "-")
+	if digits == "" || digits[0] < '0' || digits[0] > '9' {
+		return false
+	}
+	if len(digits) > 1 && digits[0] == '0' && digits[1] != '.' {
+		return false
+	}
+	for _, c := range digits {
+		switch {
+		case c >= '0' && c <= '9', c == '.', c == 'e', c == 'E', c == '+', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
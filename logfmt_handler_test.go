@@ -0,0 +1,93 @@
+package humanlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestLogfmtHandler_TypedValues(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		detectTimestamp bool
+		detectDuration  bool
+		want            []*typesv1.KV
+	}{
+		{
+			name:  "numbers and bools",
+			input: `msg=hi count=42 neg=-7 ratio=0.25 big=1e6 ok=true failed=false`,
+			want: []*typesv1.KV{
+				typesv1.KeyVal("count", typesv1.ValI64(42)),
+				typesv1.KeyVal("neg", typesv1.ValI64(-7)),
+				typesv1.KeyVal("ratio", typesv1.ValF64(0.25)),
+				typesv1.KeyVal("big", typesv1.ValF64(1e6)),
+				typesv1.KeyVal("ok", typesv1.ValBool(true)),
+				typesv1.KeyVal("failed", typesv1.ValBool(false)),
+			},
+		},
+		{
+			name:  "numbers that wouldn't print back the same",
+			input: `msg=hi id=12345678901234567890 v=1.10 pi=3.14159265358979323846 small=1.5e-3 neg=-2E+2`,
+			want: []*typesv1.KV{
+				typesv1.KeyVal("id", typesv1.ValStr("12345678901234567890")),
+				typesv1.KeyVal("v", typesv1.ValStr("1.10")),
+				typesv1.KeyVal("pi", typesv1.ValStr("3.14159265358979323846")),
+				typesv1.KeyVal("small", typesv1.ValF64(1.5e-3)),
+				typesv1.KeyVal("neg", typesv1.ValF64(-2e2)),
+			},
+		},
+		{
+			name:  "strings that only look typed",
+			input: `msg=hi zip=02134 version=1.2.3 nan=NaN t=True took=150ms at=2024-01-02T15:04:05Z`,
+			want: []*typesv1.KV{
+				typesv1.KeyVal("zip", typesv1.ValStr("02134")),
+				typesv1.KeyVal("version", typesv1.ValStr("1.2.3")),
+				typesv1.KeyVal("nan", typesv1.ValStr("NaN")),
+				typesv1.KeyVal("t", typesv1.ValStr("True")),
+				typesv1.KeyVal("took", typesv1.ValStr("150ms")),
+				typesv1.KeyVal("at", typesv1.ValStr("2024-01-02T15:04:05Z")),
+			},
+		},
+		{
+			name:            "durations and timestamps when detected",
+			input:           `msg=hi took=150ms uptime=1h2m3.5s zero=0 at=2024-01-02T15:04:05Z`,
+			detectTimestamp: true,
+			detectDuration:  true,
+			want: []*typesv1.KV{
+				typesv1.KeyVal("took", typesv1.ValDuration(150*time.Millisecond)),
+				typesv1.KeyVal("uptime", typesv1.ValDuration(time.Hour+2*time.Minute+3500*time.Millisecond)),
+				typesv1.KeyVal("zero", typesv1.ValI64(0)),
+				typesv1.KeyVal("at", typesv1.ValTime(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))),
+			},
+		},
+		{
+			name:           "durations in iso 8601 and in numbers of some unit",
+			input:          `msg=hi wait=PT1M30S elapsed_ns=1500 latency_ms=2.5 count=3`,
+			detectDuration: true,
+			want: []*typesv1.KV{
+				typesv1.KeyVal("wait", typesv1.ValDuration(90*time.Second)),
+				typesv1.KeyVal("elapsed_ns", typesv1.ValDuration(1500*time.Nanosecond)),
+				typesv1.KeyVal("latency_ms", typesv1.ValDuration(2500*time.Microsecond)),
+				typesv1.KeyVal("count", typesv1.ValI64(3)),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.DetectTimestamp = tt.detectTimestamp
+			opts.DetectDuration = tt.detectDuration
+			h := LogfmtHandler{Opts: opts}
+
+			got := new(typesv1.Log)
+			require.True(t, h.TryHandle([]byte(tt.input), got))
+			diff := cmp.Diff(tt.want, got.Attributes, protocmp.Transform())
+			require.Empty(t, diff)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package humanlog
+
+import (
+	"bytes"
+	"regexp"
+	"time"
+
+	typesv1 "github.com/minitape/api/go/types/v1"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+)
+
+// MultilineOptions configures how continuation lines, like the frames of
+// a stack trace, are attached to the structured event that precedes them
+// instead of being emitted as events of their own.
+type MultilineOptions struct {
+	// ContinuationPatterns match lines that continue the previous event.
+	ContinuationPatterns []*regexp.Regexp
+	// Key is the attribute under which continuation lines are stored.
+	Key string
+	// MaxLines caps how many lines are attached to a single event, past
+	// which the event is emitted and the lines go through as-is.
+	MaxLines int
+	// FlushAfter is how long an event is held waiting for continuation
+	// lines before it's emitted anyways.
+	FlushAfter time.Duration
+}
+
+var DefaultMultilineOptions = func() *MultilineOptions {
+	return &MultilineOptions{
+		ContinuationPatterns: []*regexp.Regexp{
+			// indented lines: java/js `at ...` frames, python `File ...`
+			// frames, go file:line frames, zap context lines
+			regexp.MustCompile(`^\s+\S`),
+			regexp.MustCompile(`^at \S`),
+			// python
+			regexp.MustCompile(`^Traceback \(most recent call last\):`),
+			regexp.MustCompile(`^[\w.]+(Error|Exception|Warning)(: |$)`),
+			// java
+			regexp.MustCompile(`^Caused by: `),
+			regexp.MustCompile(`^\.\.\. \d+ more`),
+			// go
+			regexp.MustCompile(`^goroutine \d+ \[`),
+			regexp.MustCompile(`^[\w./-]+(\.\(\*?\w+\))?\.\w+[\w.]*\(.*\)$`),
+			regexp.MustCompile(`^created by `),
+		},
+		Key:        string(semconv.ExceptionStacktraceKey),
+		MaxLines:   1000,
+		FlushAfter: 200 * time.Millisecond,
+	}
+}
+
+// coalescer holds on to the last structured event until it's clear that
+// no more continuation lines will follow it.
+type coalescer struct {
+	opts *MultilineOptions
+
+	pending *typesv1.Log
+	lines   [][]byte
+}
+
+func (co *coalescer) isContinuation(line []byte) bool {
+	if co.pending == nil || len(co.lines) >= co.opts.MaxLines {
+		return false
+	}
+	for _, re := range co.opts.ContinuationPatterns {
+		if re.Match(line) {
+			return true
+		}
+	}
+	return false
+}
+
+func (co *coalescer) hold(ev *typesv1.Log) {
+	co.pending = ev
+	co.lines = co.lines[:0]
+}
+
+func (co *coalescer) attach(line []byte) {
+	co.lines = append(co.lines, line)
+}
+
+// release returns the pending event, if any, with its continuation lines
+// attached.
+func (co *coalescer) release() *typesv1.Log {
+	ev := co.pending
+	co.pending = nil
+	if ev == nil || len(co.lines) == 0 {
+		return ev
+	}
+	stack := bytes.Join(co.lines, []byte("\n"))
+	ev.Attributes = append(ev.Attributes, typesv1.KeyVal(co.opts.Key, typesv1.ValStr(string(stack))))
+	raw := make([]byte, 0, len(ev.Raw)+1+len(stack))
+	raw = append(raw, ev.Raw...)
+	raw = append(raw, '\n')
+	raw = append(raw, stack...)
+	ev.Raw = raw
+	return ev
+}
@@ -0,0 +1,166 @@
+package humanlog
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"github.com/humanlogio/humanlog/pkg/sink/bufsink"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestScannerMultiline(t *testing.T) {
+	now := time.Date(2024, 10, 11, 15, 25, 6, 0, time.UTC)
+	tests := []struct {
+		name  string
+		input string
+		want  []*typesv1.Log
+	}{
+		{
+			name: "java stack trace",
+			input: `{"level":"error","msg":"request failed"}
+java.lang.IllegalStateException: boom
+	at com.example.Foo.bar(Foo.java:42)
+	at com.example.Main.main(Main.java:7)
+Caused by: java.io.IOException: disk full
+	... 2 more
+{"level":"info","msg":"recovered"}`,
+			want: []*typesv1.Log{
+				{
+					ObservedTimestamp: timestamppb.New(now),
+					SeverityText:      "error",
+					SeverityNumber:    17,
+					Body:              "request failed",
+					Raw: []byte(`{"level":"error","msg":"request failed"}
+java.lang.IllegalStateException: boom
+	at com.example.Foo.bar(Foo.java:42)
+	at com.example.Main.main(Main.java:7)
+Caused by: java.io.IOException: disk full
+	... 2 more`),
+					Attributes: []*typesv1.KV{
+						typesv1.KeyVal("exception.stacktrace", typesv1.ValStr(`java.lang.IllegalStateException: boom
+	at com.example.Foo.bar(Foo.java:42)
+	at com.example.Main.main(Main.java:7)
+Caused by: java.io.IOException: disk full
+	... 2 more`)),
+					},
+				},
+				{
+					ObservedTimestamp: timestamppb.New(now),
+					SeverityText:      "info",
+					SeverityNumber:    9,
+					Body:              "recovered",
+					Raw:               []byte(`{"level":"info","msg":"recovered"}`),
+				},
+			},
+		},
+		{
+			name: "python traceback",
+			input: `level=error msg="unhandled"
+Traceback (most recent call last):
+  File "app.py", line 3, in <module>
+    main()
+ZeroDivisionError: division by zero`,
+			want: []*typesv1.Log{
+				{
+					ObservedTimestamp: timestamppb.New(now),
+					SeverityText:      "error",
+					SeverityNumber:    17,
+					Body:              "unhandled",
+					Raw: []byte(`level=error msg="unhandled"
+Traceback (most recent call last):
+  File "app.py", line 3, in <module>
+    main()
+ZeroDivisionError: division by zero`),
+					Attributes: []*typesv1.KV{
+						typesv1.KeyVal("exception.stacktrace", typesv1.ValStr(`Traceback (most recent call last):
+  File "app.py", line 3, in <module>
+    main()
+ZeroDivisionError: division by zero`)),
+					},
+				},
+			},
+		},
+		{
+			name: "go panic",
+			input: `{"level":"fatal","msg":"panic"}
+goroutine 1 [running]:
+main.main()
+	/src/main.go:12 +0x1d`,
+			want: []*typesv1.Log{
+				{
+					ObservedTimestamp: timestamppb.New(now),
+					SeverityText:      "fatal",
+					SeverityNumber:    21,
+					Body:              "panic",
+					Raw: []byte(`{"level":"fatal","msg":"panic"}
+goroutine 1 [running]:
+main.main()
+	/src/main.go:12 +0x1d`),
+					Attributes: []*typesv1.KV{
+						typesv1.KeyVal("exception.stacktrace", typesv1.ValStr(`goroutine 1 [running]:
+main.main()
+	/src/main.go:12 +0x1d`)),
+					},
+				},
+			},
+		},
+		{
+			name: "continuation without a structured event",
+			input: `hello
+	world
+{"msg":"hi"}
+not a continuation`,
+			want: []*typesv1.Log{
+				{ObservedTimestamp: timestamppb.New(now), Raw: []byte(`hello`)},
+				{ObservedTimestamp: timestamppb.New(now), Raw: []byte("\tworld")},
+				{ObservedTimestamp: timestamppb.New(now), Raw: []byte(`{"msg":"hi"}`), Body: "hi"},
+				{ObservedTimestamp: timestamppb.New(now), Raw: []byte(`not a continuation`)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			src := strings.NewReader(tt.input)
+			opts := DefaultOptions()
+			opts.Multiline = DefaultMultilineOptions()
+			opts.newULID = func() *typesv1.ULID { return nil }
+			opts.timeNow = func() time.Time {
+				return now
+			}
+
+			sink := bufsink.NewSizedBufferedSink(100, nil)
+			err := Scan(ctx, src, sink, opts)
+			require.NoError(t, err, "got %#v", err)
+
+			got := sink.Buffered
+			require.Len(t, got, len(tt.want))
+			for i, gotl := range got {
+				diff := cmp.Diff(tt.want[i], gotl, protocmp.Transform())
+				require.Empty(t, diff, "log %d", i)
+			}
+		})
+	}
+}
+
+func TestScannerMultilineMaxLines(t *testing.T) {
+	ctx := context.Background()
+	src := strings.NewReader("{\"msg\":\"hi\"}\n\tat a\n\tat b\n\tat c")
+	opts := DefaultOptions()
+	opts.Multiline = DefaultMultilineOptions()
+	opts.Multiline.MaxLines = 2
+	opts.newULID = func() *typesv1.ULID { return nil }
+
+	sink := bufsink.NewSizedBufferedSink(100, nil)
+	require.NoError(t, Scan(ctx, src, sink, opts))
+
+	require.Len(t, sink.Buffered, 2)
+	require.Equal(t, "\tat a\n\tat b", sink.Buffered[0].Attributes[0].Value.GetStr())
+	require.Equal(t, "\tat c", string(sink.Buffered[1].Raw))
+}
@@ -0,0 +1,268 @@
+package humanlog
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// DefaultPatternLibrary holds the sub-patterns that can be used in
+// patterns as `%{NAME}`, named like their grok counterparts.
+var DefaultPatternLibrary = map[string]string{
+	"WORD":       `\b\w+\b`,
+	"NOTSPACE":   `\S+`,
+	"SPACE":      `\s*`,
+	"DATA":       `.*?`,
+	"GREEDYDATA": `.*`,
+	"QS":         `"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'`,
+
+	"INT":       `[+-]?\d+`,
+	"POSINT":    `\b[1-9]\d*\b`,
+	"NONNEGINT": `\b\d+\b`,
+	"NUMBER":    `[+-]?(?:\d+(?:\.\d*)?|\.\d+)`,
+	"BASE16NUM": `(?:0[xX])?[0-9A-Fa-f]+`,
+	"UUID":      `[A-Fa-f0-9]{8}-(?:[A-Fa-f0-9]{4}-){3}[A-Fa-f0-9]{12}`,
+
+	"IPV4":     `(?:\d{1,3}\.){3}\d{1,3}`,
+	"IPV6":     `[0-9A-Fa-f:]*:[0-9A-Fa-f:.]+`,
+	"IP":       `%{IPV6}|%{IPV4}`,
+	"HOSTNAME": `\b[0-9A-Za-z][0-9A-Za-z-]{0,62}(?:\.[0-9A-Za-z][0-9A-Za-z-]{0,62})*\.?\b`,
+	"IPORHOST": `%{IP}|%{HOSTNAME}`,
+	"HOSTPORT": `%{IPORHOST}:%{POSINT}`,
+	"PATH":     `(?:/[^\s]*)+|(?:[A-Za-z]:|\\)(?:\\[^\s]*)+`,
+	"URIPATH":  `/[^\s?#]*`,
+	"URIPARAM": `\?[^\s#]*`,
+	"URI":      `[A-Za-z][A-Za-z0-9+.-]*://\S+`,
+
+	"JAVACLASS": `(?:[a-zA-Z$_][a-zA-Z$_0-9]*\.)*[a-zA-Z$_][a-zA-Z$_0-9]*`,
+	"LOGLEVEL":  `(?i:TRACE|DEBUG|DBG|INFO|INF|NOTICE|WARN|WARNING|WRN|ERROR|ERR|CRIT|CRITICAL|FATAL|FTL|PANIC|ALERT|EMERG|SEVERE|FINE|FINER|FINEST|CONFIG|VERBOSE)`,
+
+	"YEAR":              `\d{4}`,
+	"MONTHNUM":          `0?[1-9]|1[0-2]`,
+	"MONTHDAY":          `0[1-9]|[12]\d|3[01]|[1-9]`,
+	"MONTH":             `\b(?:Jan(?:uary)?|Feb(?:ruary)?|Mar(?:ch)?|Apr(?:il)?|May|June?|July?|Aug(?:ust)?|Sep(?:tember)?|Oct(?:ober)?|Nov(?:ember)?|Dec(?:ember)?)\b`,
+	"DAY":               `\b(?:Mon(?:day)?|Tue(?:sday)?|Wed(?:nesday)?|Thu(?:rsday)?|Fri(?:day)?|Sat(?:urday)?|Sun(?:day)?)\b`,
+	"HOUR":              `2[0-3]|[01]?\d`,
+	"MINUTE":            `[0-5]\d`,
+	"SECOND":            `(?:[0-5]?\d|60)(?:[.,]\d+)?`,
+	"TIME":              `%{HOUR}:%{MINUTE}(?::%{SECOND})?`,
+	"ISO8601_TIMEZONE":  `Z|[+-]%{HOUR}(?::?%{MINUTE})?`,
+	"TIMESTAMP_ISO8601": `%{YEAR}-%{MONTHNUM}-%{MONTHDAY}[T ]%{HOUR}:?%{MINUTE}(?::?%{SECOND})?(?:%{ISO8601_TIMEZONE})?`,
+	"HTTPDATE":          `%{MONTHDAY}/%{MONTH}/%{YEAR}:%{TIME} [+-]\d{4}`,
+	"SYSLOGTIMESTAMP":   `%{MONTH} +%{MONTHDAY} %{TIME}`,
+}
+
+// patternRefRe matches the references to sub-patterns in a pattern:
+// `%{NAME}`, `%{NAME:capture}` or `%{NAME:capture:type}`.
+var patternRefRe = regexp.MustCompile(`%\{(\w+)(?::([\w.@-]+))?(?::(int|float|string))?\}`)
+
+// maxPatternDepth bounds how deeply sub-patterns can refer to others,
+// catching cycles.
+const maxPatternDepth = 32
+
+// Pattern parses text lines that it matches. The captures named like
+// one of the time, message or level fields of the handler options set
+// these, and the others become attributes.
+type Pattern struct {
+	Name   string
+	Regexp *regexp.Regexp
+	// TimeLayout is the Go layout of the timestamp. When empty, the usual
+	// layouts are tried. If it has no year, or no date at all, they're
+	// assumed to be recent.
+	TimeLayout string
+	// Levels renames the levels captured, like `I` to `info`.
+	Levels map[string]string
+
+	// captures are the names of the groups of Regexp, which can't
+	// be used as is since they aren't valid group names
+	captures []string
+	// types are the types the captures are converted to
+	types []string
+	// finish completes what was captured
+	finish func(ev *typesv1.Log)
+}
+
+// CompilePattern compiles a pattern made of regexps and `%{NAME}`
+// references to sub-patterns, found in `definitions` or in
+// DefaultPatternLibrary. `%{NAME:capture}` captures what the
+// sub-pattern matched, and `%{NAME:capture:int}` or
+// `%{NAME:capture:float}` converts it to a number. Regular named groups
+// capture too. The pattern must match whole lines.
+func CompilePattern(name, pattern string, definitions map[string]string) (*Pattern, error) {
+	p := &Pattern{Name: name}
+	expanded, err := p.expand(pattern, definitions, 0)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(expanded)
+	if err != nil {
+		return nil, err
+	}
+	// the named groups written as such in the pattern
+	for i, group := range re.SubexpNames() {
+		if i == 0 || group == "" || strings.HasPrefix(group, "_c") {
+			continue
+		}
+		p.captures = append(p.captures, group)
+		p.types = append(p.types, "string")
+		renamed := fmt.Sprintf("(?P<_c%d>", len(p.captures)-1)
+		expanded = strings.Replace(expanded, "(?P<"+group+">", renamed, 1)
+		expanded = strings.Replace(expanded, "(?<"+group+">", renamed, 1)
+	}
+	// patterns match whole lines
+	p.Regexp, err = regexp.Compile("^(?:" + expanded + ")$")
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Pattern) expand(pattern string, definitions map[string]string, depth int) (string, error) {
+	if depth > maxPatternDepth {
+		return "", fmt.Errorf("sub-patterns of %q nest too deeply, is one of them referring to itself?", p.Name)
+	}
+	var err error
+	expanded := patternRefRe.ReplaceAllStringFunc(pattern, func(ref string) string {
+		if err != nil {
+			return ""
+		}
+		m := patternRefRe.FindStringSubmatch(ref)
+		sub, ok := definitions[m[1]]
+		if !ok {
+			sub, ok = DefaultPatternLibrary[m[1]]
+		}
+		if !ok {
+			err = fmt.Errorf("unknown sub-pattern %q", m[1])
+			return ""
+		}
+		sub, err = p.expand(sub, definitions, depth+1)
+		if m[2] == "" {
+			return "(?:" + sub + ")"
+		}
+		typ := m[3]
+		if typ == "" {
+			typ = "string"
+		}
+		p.captures = append(p.captures, m[2])
+		p.types = append(p.types, typ)
+		return fmt.Sprintf("(?P<_c%d>%s)", len(p.captures)-1, sub)
+	})
+	return expanded, err
+}
+
+// TryHandle parses `d` into `ev` if the pattern matches it.
+func (p *Pattern) TryHandle(d []byte, ev *typesv1.Log, opts *HandlerOptions) bool {
+	matches := p.Regexp.FindSubmatchIndex(d)
+	if matches == nil {
+		return false
+	}
+	var (
+		ts       time.Time
+		msg, lvl string
+		attrs    []*typesv1.KV
+	)
+	for i, group := range p.Regexp.SubexpNames() {
+		if i == 0 || !strings.HasPrefix(group, "_c") || matches[2*i] < 0 {
+			continue
+		}
+		idx, err := strconv.Atoi(group[2:])
+		if err != nil {
+			continue
+		}
+		key, typ := p.captures[idx], p.types[idx]
+		val := string(d[matches[2*i]:matches[2*i+1]])
+
+		if ts.IsZero() && isOneOf(key, opts.TimeFields) {
+			if t, ok := p.parseTime(val, opts.timeNow()); ok {
+				ts = t
+				continue
+			}
+		}
+		if msg == "" && isOneOf(key, opts.MessageFields) {
+			msg = val
+			continue
+		}
+		if lvl == "" && isOneOf(key, opts.LevelFields) {
+			lvl = val
+			continue
+		}
+		if val == "" {
+			continue
+		}
+		attrs = append(attrs, typesv1.KeyVal(key, typedPatternValue(val, typ)))
+	}
+	if !ts.IsZero() {
+		ev.Timestamp = timestamppb.New(ts)
+	}
+	if renamed, ok := p.Levels[lvl]; ok {
+		lvl = renamed
+	}
+	ev.Body = msg
+	ev.SeverityText = lvl
+	ev.Attributes = attrs
+	if p.finish != nil {
+		p.finish(ev)
+	}
+	return true
+}
+
+func (p *Pattern) parseTime(v string, now time.Time) (time.Time, bool) {
+	if p.TimeLayout == "" {
+		return tryParseTimeString(v)
+	}
+	t, err := time.Parse(p.TimeLayout, v)
+	if err != nil {
+		return t, false
+	}
+	if t.Year() != 0 {
+		return fixTimebeforeUnixZero(t), true
+	}
+	now = now.UTC()
+	if t.Month() == time.January && t.Day() == 1 && !layoutHasDate(p.TimeLayout) {
+		t = t.AddDate(now.Year(), int(now.Month())-1, now.Day()-1)
+		// a log from before midnight read after it
+		if t.After(now.Add(time.Hour)) {
+			t = t.AddDate(0, 0, -1)
+		}
+		return t, true
+	}
+	t = t.AddDate(now.Year(), 0, 0)
+	// a log from december read in january
+	if t.After(now.AddDate(0, 1, 0)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t, true
+}
+
+// layoutHasDate tells if a Go time layout has a month in it.
+func layoutHasDate(layout string) bool {
+	return strings.Contains(layout, "01") || strings.Contains(layout, "Jan")
+}
+
+func typedPatternValue(v, typ string) *typesv1.Val {
+	switch typ {
+	case "int":
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return typesv1.ValI64(i)
+		}
+	case "float":
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return typesv1.ValF64(f)
+		}
+	}
+	return typesv1.ValStr(v)
+}
+
+func isOneOf(key string, fields []string) bool {
+	for _, field := range fields {
+		if fieldsEqualAllString(key, field) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package humanlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestPattern(t *testing.T) {
+	tests := []struct {
+		name        string
+		pattern     string
+		definitions map[string]string
+		timeLayout  string
+		input       string
+		want        *typesv1.Log
+		wantNoMatch bool
+	}{
+		{
+			name:    "log4j2",
+			pattern: `%{TIMESTAMP_ISO8601:time} \[%{DATA:thread}\] %{LOGLEVEL:level}\s+%{JAVACLASS:logger} - %{GREEDYDATA:msg}`,
+			input:   `2024-11-15 19:01:52,286 [main] WARN  o.e.r.ProductRepository - High resource usage`,
+			want: &typesv1.Log{
+				Timestamp:    timestamppb.New(time.Date(2024, 11, 15, 19, 1, 52, 286000000, time.UTC)),
+				SeverityText: "WARN",
+				Body:         "High resource usage",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("thread", typesv1.ValStr("main")),
+					typesv1.KeyVal("logger", typesv1.ValStr("o.e.r.ProductRepository")),
+				},
+			},
+		},
+		{
+			name:        "user definitions, typed captures and named groups",
+			pattern:     `\[%{MYTIME:ts}\] %{WORD:level} took %{INT:took_ms:int}ms (?P<rest>.*)`,
+			definitions: map[string]string{"MYTIME": `\d{4}/\d{2}/\d{2} \d{2}:\d{2}`},
+			timeLayout:  "2006/01/02 15:04",
+			input:       `[2024/11/15 19:01] info took 42ms and done`,
+			want: &typesv1.Log{
+				Timestamp:    timestamppb.New(time.Date(2024, 11, 15, 19, 1, 0, 0, time.UTC)),
+				SeverityText: "info",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("took_ms", typesv1.ValI64(42)),
+					typesv1.KeyVal("rest", typesv1.ValStr("and done")),
+				},
+			},
+		},
+		{
+			name:        "must match the whole line",
+			pattern:     `%{LOGLEVEL:level}`,
+			input:       `INFO and more`,
+			wantNoMatch: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := CompilePattern(tt.name, tt.pattern, tt.definitions)
+			require.NoError(t, err)
+			p.TimeLayout = tt.timeLayout
+
+			got := new(typesv1.Log)
+			ok := p.TryHandle([]byte(tt.input), got, DefaultOptions())
+			if tt.wantNoMatch {
+				require.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			diff := cmp.Diff(tt.want, got, protocmp.Transform())
+			require.Empty(t, diff)
+		})
+	}
+}
+
+func TestCompilePatternErrors(t *testing.T) {
+	_, err := CompilePattern("unknown", `%{NOPE:x}`, nil)
+	require.ErrorContains(t, err, `unknown sub-pattern "NOPE"`)
+
+	_, err = CompilePattern("cycle", `%{A}`, map[string]string{"A": `%{B}`, "B": `%{A}`})
+	require.ErrorContains(t, err, "nest too deeply")
+}
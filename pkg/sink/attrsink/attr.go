@@ -0,0 +1,41 @@
+package attrsink
+
+import (
+	"context"
+	"slices"
+
+	"github.com/humanlogio/humanlog/pkg/sink"
+	typesv1 "github.com/minitape/api/go/types/v1"
+)
+
+var _ sink.Sink = (*AttrSink)(nil)
+
+// AttrSink adds a fixed set of attributes to the resource of every event
+// before handing it to the next sink. It's used to tag events with where
+// they came from (a file, a stream, etc). Tagging the resource rather
+// than the event keeps lines that aren't structured from counting as
+// structured.
+type AttrSink struct {
+	next  sink.Sink
+	attrs []*typesv1.KV
+}
+
+func NewAttrSink(next sink.Sink, attrs ...*typesv1.KV) *AttrSink {
+	return &AttrSink{next: next, attrs: attrs}
+}
+
+func (sn *AttrSink) Receive(ctx context.Context, ev *typesv1.Log) error {
+	kvs := slices.Clone(ev.Resource.GetAttributes())
+	for _, attr := range sn.attrs {
+		// what the event tells of its resource wins
+		if !slices.ContainsFunc(kvs, func(kv *typesv1.KV) bool { return kv.Key == attr.Key }) {
+			kvs = append(kvs, attr)
+		}
+	}
+	ev.Resource = typesv1.NewResource(ev.Resource.GetSchemaUrl(), kvs)
+	return sn.next.Receive(ctx, ev)
+}
+
+func (sn *AttrSink) Close(ctx context.Context) error {
+	return sn.next.Close(ctx)
+}
@@ -0,0 +1,38 @@
+package attrsink
+
+import (
+	"context"
+	"testing"
+
+	"github.com/humanlogio/humanlog/pkg/sink/bufsink"
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttrSink(t *testing.T) {
+	ctx := context.Background()
+	buf := bufsink.NewSizedBufferedSink(10, nil)
+	snk := NewAttrSink(buf,
+		typesv1.KeyVal("log.file.name", typesv1.ValStr("app.log")),
+		typesv1.KeyVal("service.name", typesv1.ValStr("app")),
+	)
+
+	raw := &typesv1.Log{Raw: []byte("just some text")}
+	require.NoError(t, snk.Receive(ctx, raw))
+	structured := &typesv1.Log{
+		Body:     "hello",
+		Resource: typesv1.NewResource("", []*typesv1.KV{typesv1.KeyVal("service.name", typesv1.ValStr("web"))}),
+	}
+	require.NoError(t, snk.Receive(ctx, structured))
+
+	require.Len(t, buf.Buffered, 2)
+	require.False(t, buf.Buffered[0].IsStructured())
+	require.Equal(t, []*typesv1.KV{
+		typesv1.KeyVal("log.file.name", typesv1.ValStr("app.log")),
+		typesv1.KeyVal("service.name", typesv1.ValStr("app")),
+	}, buf.Buffered[0].Resource.Attributes)
+	require.Equal(t, []*typesv1.KV{
+		typesv1.KeyVal("service.name", typesv1.ValStr("web")),
+		typesv1.KeyVal("log.file.name", typesv1.ValStr("app.log")),
+	}, buf.Buffered[1].Resource.Attributes)
+}
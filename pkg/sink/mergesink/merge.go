@@ -0,0 +1,268 @@
+package mergesink
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/humanlogio/humanlog/pkg/sink"
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultMaxBuffered is how many events a MergeSink holds at most
+// before releasing them regardless of their timestamp.
+const DefaultMaxBuffered = 10000
+
+// MergeSink interleaves the events of many inputs by their timestamp.
+//
+// Each input is expected to be mostly in chronological order. An event
+// is held until every open input has gone past its timestamp, or until
+// it's been held for longer than the reorder window, or until too many
+// events are held. Events without a timestamp stick to the event that
+// preceded them in their input, so that continuation lines or unparsed
+// lines aren't scattered around.
+type MergeSink struct {
+	next        sink.Sink
+	window      time.Duration
+	maxBuffered int
+	timeNow     func() time.Time
+
+	mu       sync.Mutex
+	inputs   map[*Input]struct{}
+	pending  groupHeap
+	arrivals []*group
+	buffered int
+	seq      uint64
+	timer    *time.Timer
+	ctx      context.Context
+	err      error
+}
+
+// NewMergeSink creates a sink that merges its inputs into `next`,
+// holding events for at most `window`.
+func NewMergeSink(next sink.Sink, window time.Duration) *MergeSink {
+	return &MergeSink{
+		next:        next,
+		window:      window,
+		maxBuffered: DefaultMaxBuffered,
+		timeNow:     time.Now,
+		inputs:      make(map[*Input]struct{}),
+		ctx:         context.Background(),
+	}
+}
+
+// NewInput adds an input to merge. Inputs must be added before events
+// are sent to any of them, or their first events could be released too
+// early.
+func (sn *MergeSink) NewInput() *Input {
+	in := &Input{merge: sn}
+	sn.mu.Lock()
+	sn.inputs[in] = struct{}{}
+	sn.mu.Unlock()
+	return in
+}
+
+// Flush releases all the events held, in order.
+func (sn *MergeSink) Flush(ctx context.Context) error {
+	sn.mu.Lock()
+	defer sn.mu.Unlock()
+	if sn.timer != nil {
+		sn.timer.Stop()
+		sn.timer = nil
+	}
+	for sn.pending.Len() > 0 {
+		if err := sn.releaseTop(ctx); err != nil {
+			return err
+		}
+	}
+	return sn.err
+}
+
+// Close flushes the events held and closes the next sink.
+func (sn *MergeSink) Close(ctx context.Context) error {
+	if err := sn.Flush(ctx); err != nil {
+		return err
+	}
+	return sn.next.Close(ctx)
+}
+
+// Input is one of the inputs of a MergeSink.
+type Input struct {
+	merge *MergeSink
+
+	latest time.Time
+	last   *group
+}
+
+var _ sink.Sink = (*Input)(nil)
+
+func (in *Input) Receive(ctx context.Context, ev *typesv1.Log) error {
+	sn := in.merge
+	sn.mu.Lock()
+	defer sn.mu.Unlock()
+	if sn.err != nil {
+		return sn.err
+	}
+	sn.ctx = ctx
+
+	ev = proto.Clone(ev).(*typesv1.Log)
+	sn.buffered++
+	if ev.Timestamp == nil && in.last != nil {
+		if !in.last.released {
+			in.last.events = append(in.last.events, ev)
+			return sn.release(ctx)
+		}
+		// the predecessor is gone already, follow it as close as possible
+		in.push(in.last.ts, ev)
+		return sn.release(ctx)
+	}
+
+	var ts time.Time
+	if ev.Timestamp != nil {
+		ts = ev.Timestamp.AsTime()
+		if ts.After(in.latest) {
+			in.latest = ts
+		}
+	} else {
+		ts = ev.ObservedTimestamp.AsTime()
+	}
+	in.push(ts, ev)
+	return sn.release(ctx)
+}
+
+// Close tells that the input has ended, so that the other inputs don't
+// wait on it anymore.
+func (in *Input) Close(ctx context.Context) error {
+	sn := in.merge
+	sn.mu.Lock()
+	defer sn.mu.Unlock()
+	delete(sn.inputs, in)
+	return sn.release(ctx)
+}
+
+func (in *Input) push(ts time.Time, ev *typesv1.Log) {
+	sn := in.merge
+	sn.seq++
+	g := &group{ts: ts, seq: sn.seq, arrived: sn.timeNow(), events: []*typesv1.Log{ev}}
+	heap.Push(&sn.pending, g)
+	sn.arrivals = append(sn.arrivals, g)
+	in.last = g
+}
+
+// watermark is the time that all open inputs have reached. Nothing that
+// comes later is expected to be older.
+func (sn *MergeSink) watermark() (time.Time, bool) {
+	var (
+		wm    time.Time
+		found bool
+	)
+	for in := range sn.inputs {
+		if in.latest.IsZero() {
+			return time.Time{}, false
+		}
+		if !found || in.latest.Before(wm) {
+			wm = in.latest
+			found = true
+		}
+	}
+	return wm, found
+}
+
+// release sends out the events that can't be reordered anymore, and
+// makes sure the others are sent out once they've been held too long.
+func (sn *MergeSink) release(ctx context.Context) error {
+	wm, hasWM := sn.watermark()
+	allClosed := len(sn.inputs) == 0
+	now := sn.timeNow()
+	for sn.pending.Len() > 0 {
+		top := sn.pending[0]
+		switch {
+		case allClosed,
+			hasWM && !top.ts.After(wm),
+			sn.buffered > sn.maxBuffered,
+			now.Sub(sn.oldestArrival()) >= sn.window:
+		default:
+			sn.schedule(now)
+			return nil
+		}
+		if err := sn.releaseTop(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sn *MergeSink) releaseTop(ctx context.Context) error {
+	g := heap.Pop(&sn.pending).(*group)
+	g.released = true
+	sn.buffered -= len(g.events)
+	for _, ev := range g.events {
+		if err := sn.next.Receive(ctx, ev); err != nil {
+			sn.err = err
+			return err
+		}
+	}
+	return nil
+}
+
+// oldestArrival is when the group held the longest arrived.
+func (sn *MergeSink) oldestArrival() time.Time {
+	for len(sn.arrivals) > 0 && sn.arrivals[0].released {
+		sn.arrivals[0] = nil
+		sn.arrivals = sn.arrivals[1:]
+	}
+	if len(sn.arrivals) == 0 {
+		return sn.timeNow()
+	}
+	return sn.arrivals[0].arrived
+}
+
+// schedule makes sure the held events get released once their window
+// is over, even if no more events come.
+func (sn *MergeSink) schedule(now time.Time) {
+	if sn.timer != nil {
+		return
+	}
+	wait := sn.window - now.Sub(sn.oldestArrival())
+	sn.timer = time.AfterFunc(wait, func() {
+		sn.mu.Lock()
+		defer sn.mu.Unlock()
+		sn.timer = nil
+		if sn.err != nil {
+			return
+		}
+		// errors are returned to the inputs on their next event
+		_ = sn.release(sn.ctx)
+	})
+}
+
+// group is an event with a timestamp, and the events without one that
+// came right after it.
+type group struct {
+	ts       time.Time
+	seq      uint64
+	arrived  time.Time
+	events   []*typesv1.Log
+	released bool
+}
+
+type groupHeap []*group
+
+func (h groupHeap) Len() int { return len(h) }
+func (h groupHeap) Less(i, j int) bool {
+	if h[i].ts.Equal(h[j].ts) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].ts.Before(h[j].ts)
+}
+func (h groupHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *groupHeap) Push(x any)   { *h = append(*h, x.(*group)) }
+func (h *groupHeap) Pop() any {
+	old := *h
+	g := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return g
+}
@@ -0,0 +1,123 @@
+package mergesink
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/humanlogio/humanlog/pkg/sink/bufsink"
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestMergeSink(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(body string, sec int) *typesv1.Log {
+		return &typesv1.Log{Body: body, Timestamp: timestamppb.New(t0.Add(time.Duration(sec) * time.Second))}
+	}
+	untimed := func(body string) *typesv1.Log {
+		return &typesv1.Log{Body: body, ObservedTimestamp: timestamppb.New(t0)}
+	}
+
+	type event struct {
+		input int
+		ev    *typesv1.Log
+		// advance the clock before receiving the event
+		after time.Duration
+	}
+	tests := []struct {
+		name   string
+		inputs int
+		events []event
+		// bodies released before the inputs are closed
+		wantEarly []string
+		want      []string
+	}{
+		{
+			name:   "interleaves by timestamp",
+			inputs: 2,
+			events: []event{
+				{input: 0, ev: at("a1", 1)},
+				{input: 0, ev: at("a3", 3)},
+				{input: 0, ev: at("a5", 5)},
+				{input: 1, ev: at("b2", 2)},
+				{input: 1, ev: at("b4", 4)},
+			},
+			wantEarly: []string{"a1", "b2", "a3", "b4"},
+			want:      []string{"a1", "b2", "a3", "b4", "a5"},
+		},
+		{
+			name:   "untimed events stick to their predecessor",
+			inputs: 2,
+			events: []event{
+				{input: 0, ev: at("a1", 1)},
+				{input: 0, ev: at("a3", 3)},
+				{input: 0, ev: untimed("a3 continued")},
+				{input: 1, ev: at("b2", 2)},
+				{input: 1, ev: untimed("b2 continued")},
+				{input: 1, ev: at("b4", 4)},
+			},
+			wantEarly: []string{"a1", "b2", "b2 continued", "a3", "a3 continued"},
+			want:      []string{"a1", "b2", "b2 continued", "a3", "a3 continued", "b4"},
+		},
+		{
+			name:   "events held longer than the window are released",
+			inputs: 2,
+			events: []event{
+				{input: 0, ev: at("a1", 1)},
+				{input: 0, ev: at("a2", 2)},
+				{input: 0, ev: at("a3", 3), after: time.Minute},
+			},
+			wantEarly: []string{"a1", "a2"},
+			want:      []string{"a1", "a2", "a3"},
+		},
+		{
+			name:   "late events are released right away",
+			inputs: 2,
+			events: []event{
+				{input: 0, ev: at("a1", 1)},
+				{input: 0, ev: at("a3", 3)},
+				{input: 1, ev: at("b2", 2)},
+				{input: 1, ev: at("b4", 4)},
+				{input: 0, ev: at("a0", 0)},
+			},
+			wantEarly: []string{"a1", "b2", "a3", "a0"},
+			want:      []string{"a1", "b2", "a3", "a0", "b4"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			now := t0
+			out := bufsink.NewSizedBufferedSink(100, nil)
+			merge := NewMergeSink(out, time.Hour)
+			merge.window = 30 * time.Second
+			merge.timeNow = func() time.Time { return now }
+
+			var inputs []*Input
+			for range tt.inputs {
+				inputs = append(inputs, merge.NewInput())
+			}
+			for _, e := range tt.events {
+				now = now.Add(e.after)
+				require.NoError(t, inputs[e.input].Receive(ctx, e.ev))
+			}
+			require.Equal(t, tt.wantEarly, bodies(out.Buffered))
+
+			for _, in := range inputs {
+				require.NoError(t, in.Close(ctx))
+			}
+			require.NoError(t, merge.Flush(ctx))
+			require.Equal(t, tt.want, bodies(out.Buffered))
+		})
+	}
+}
+
+func bodies(evs []*typesv1.Log) []string {
+	var out []string
+	for _, ev := range evs {
+		out = append(out, ev.Body)
+	}
+	return out
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"log/slog"
+	"slices"
 	"time"
 
 	typesv1 "github.com/minitape/api/go/types/v1"
@@ -49,25 +50,14 @@ func StartOTLPSink(
 		doneFlushing: make(chan struct{}),
 	}
 
-	var (
-		otelresource = &otlpresource.Resource{
-			Attributes: typesv1.ToOTLPKVs(resource.Attributes),
-		}
-		otelscope = &otlpcommon.InstrumentationScope{
-			Name:       scope.Name,
-			Version:    scope.Version,
-			Attributes: typesv1.ToOTLPKVs(scope.Attributes),
-		}
-	)
-
 	go func() {
 		var (
-			buffered []*otellogpb.LogRecord
+			buffered []*typesv1.Log
 			err      error
 		)
 		for {
 			startedAt := time.Now()
-			buffered, err = snk.connectAndHandleBuffer(ctx, client, otelresource, otelscope, bufferSize, drainBufferFor, buffered)
+			buffered, err = snk.connectAndHandleBuffer(ctx, client, resource, scope, bufferSize, drainBufferFor, buffered)
 			if err == io.EOF {
 				close(snk.doneFlushing)
 				return
@@ -130,15 +120,89 @@ func internalToOTLPLog(in *typesv1.Log) *otellogpb.LogRecord {
 	return out
 }
 
+// groupResourceLogs groups logs by their resource, and then by their
+// scope, in the order they come. Logs without a resource or a scope of
+// their own are sent with `resource` and `scope`.
+func groupResourceLogs(logs []*typesv1.Log, resource *typesv1.Resource, scope *typesv1.Scope) []*otellogpb.ResourceLogs {
+	var (
+		out       []*otellogpb.ResourceLogs
+		resources = make(map[uint64]*otellogpb.ResourceLogs)
+		scopes    = make(map[[2]uint64]*otellogpb.ScopeLogs)
+	)
+	for _, ev := range logs {
+		res := resourceOf(ev, resource)
+		resHash := typesv1.Hash64Resource(res.GetSchemaUrl(), typesv1.KVsToValuer(res.GetAttributes()))
+		rl, ok := resources[resHash]
+		if !ok {
+			rl = &otellogpb.ResourceLogs{
+				Resource:  &otlpresource.Resource{Attributes: typesv1.ToOTLPKVs(res.GetAttributes())},
+				SchemaUrl: res.GetSchemaUrl(),
+			}
+			resources[resHash] = rl
+			out = append(out, rl)
+		}
+
+		sc := scope
+		if ev.Scope.GetName() != "" {
+			sc = ev.Scope
+		}
+		scopeHash := typesv1.Hash64Scope(sc.GetSchemaUrl(), sc.GetName(), sc.GetVersion(), typesv1.KVsToValuer(sc.GetAttributes()))
+		sl, ok := scopes[[2]uint64{resHash, scopeHash}]
+		if !ok {
+			sl = &otellogpb.ScopeLogs{
+				Scope: &otlpcommon.InstrumentationScope{
+					Name:       sc.GetName(),
+					Version:    sc.GetVersion(),
+					Attributes: typesv1.ToOTLPKVs(sc.GetAttributes()),
+				},
+				SchemaUrl: sc.GetSchemaUrl(),
+			}
+			scopes[[2]uint64{resHash, scopeHash}] = sl
+			rl.ScopeLogs = append(rl.ScopeLogs, sl)
+		}
+		sl.LogRecords = append(sl.LogRecords, internalToOTLPLog(ev))
+	}
+	return out
+}
+
+// resourceOf is the resource of `ev`, completed by `dflt`. The service
+// name of `ev` is its `service.name`, if it has none.
+func resourceOf(ev *typesv1.Log, dflt *typesv1.Resource) *typesv1.Resource {
+	if len(ev.Resource.GetAttributes()) == 0 && ev.ServiceName == "" {
+		return dflt
+	}
+	var (
+		kvs  = slices.Clone(ev.Resource.GetAttributes())
+		seen = make(map[string]struct{}, len(kvs))
+	)
+	for _, kv := range kvs {
+		seen[kv.Key] = struct{}{}
+	}
+	if _, ok := seen[string(semconv.ServiceNameKey)]; !ok && ev.ServiceName != "" {
+		kvs = append(kvs, typesv1.KeyVal(string(semconv.ServiceNameKey), typesv1.ValStr(ev.ServiceName)))
+		seen[string(semconv.ServiceNameKey)] = struct{}{}
+	}
+	for _, kv := range dflt.GetAttributes() {
+		if _, ok := seen[kv.Key]; !ok {
+			kvs = append(kvs, kv)
+		}
+	}
+	schemaURL := ev.Resource.GetSchemaUrl()
+	if schemaURL == "" {
+		schemaURL = dflt.GetSchemaUrl()
+	}
+	return typesv1.NewResource(schemaURL, kvs)
+}
+
 func (snk *OTLPSink) connectAndHandleBuffer(
 	ctx context.Context,
 	client collogpb.LogsServiceClient,
-	resource *otlpresource.Resource,
-	scope *otlpcommon.InstrumentationScope,
+	resource *typesv1.Resource,
+	scope *typesv1.Scope,
 	bufferSize int,
 	drainBufferFor time.Duration,
-	buffered []*otellogpb.LogRecord,
-) (lastBuffer []*otellogpb.LogRecord, _ error) {
+	buffered []*typesv1.Log,
+) (lastBuffer []*typesv1.Log, _ error) {
 	ll := snk.ll
 	ll.DebugContext(ctx, "contacting log ingestor")
 
@@ -155,7 +219,7 @@ func (snk *OTLPSink) connectAndHandleBuffer(
 					flushing = true
 				}
 				if ev != nil {
-					buffered = append(buffered, internalToOTLPLog(ev))
+					buffered = append(buffered, ev)
 				}
 			case <-ctx.Done():
 				return buffered, nil
@@ -170,7 +234,7 @@ func (snk *OTLPSink) connectAndHandleBuffer(
 			select {
 			case ev, more := <-snk.eventsc:
 				if ev != nil {
-					buffered = append(buffered, internalToOTLPLog(ev))
+					buffered = append(buffered, ev)
 				}
 				if !more {
 					ll.DebugContext(ctx, "no more events coming, flushing buffer (while draining)")
@@ -185,13 +249,9 @@ func (snk *OTLPSink) connectAndHandleBuffer(
 		}
 		// until it's empty, then send what we have
 		start := time.Now()
-		res, err := client.Export(ctx, &collogpb.ExportLogsServiceRequest{ResourceLogs: []*otellogpb.ResourceLogs{{
-			Resource: resource,
-			ScopeLogs: []*otellogpb.ScopeLogs{{
-				Scope:      scope,
-				LogRecords: buffered,
-			}},
-		}}})
+		res, err := client.Export(ctx, &collogpb.ExportLogsServiceRequest{
+			ResourceLogs: groupResourceLogs(buffered, resource, scope),
+		})
 		if err != nil {
 			return buffered, err
 		}
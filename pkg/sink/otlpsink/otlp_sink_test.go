@@ -0,0 +1,59 @@
+package otlpink
+
+import (
+	"testing"
+
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupResourceLogs(t *testing.T) {
+	dflt := typesv1.NewResource("", []*typesv1.KV{
+		typesv1.KeyVal("host.name", typesv1.ValStr("laptop")),
+	})
+	scope := typesv1.NewScope("", "humanlog", "v1", nil)
+	web := typesv1.NewResource("", []*typesv1.KV{
+		typesv1.KeyVal("service.name", typesv1.ValStr("web")),
+	})
+	logs := []*typesv1.Log{
+		{Body: "a", Resource: web},
+		{Body: "b"},
+		{Body: "c", ServiceName: "web"},
+		{Body: "d", Resource: web, Scope: typesv1.NewScope("", "net/http", "", nil)},
+		{Body: "e", ServiceName: "db"},
+	}
+
+	got := groupResourceLogs(logs, dflt, scope)
+
+	type group struct {
+		resource map[string]string
+		scopes   map[string][]string
+	}
+	var groups []group
+	for _, rl := range got {
+		g := group{resource: make(map[string]string), scopes: make(map[string][]string)}
+		for _, kv := range rl.Resource.Attributes {
+			g.resource[kv.Key] = kv.Value.GetStringValue()
+		}
+		for _, sl := range rl.ScopeLogs {
+			for _, rec := range sl.LogRecords {
+				g.scopes[sl.Scope.Name] = append(g.scopes[sl.Scope.Name], rec.Body.GetStringValue())
+			}
+		}
+		groups = append(groups, g)
+	}
+	require.Equal(t, []group{
+		{
+			resource: map[string]string{"service.name": "web", "host.name": "laptop"},
+			scopes:   map[string][]string{"humanlog": {"a", "c"}, "net/http": {"d"}},
+		},
+		{
+			resource: map[string]string{"host.name": "laptop"},
+			scopes:   map[string][]string{"humanlog": {"b"}},
+		},
+		{
+			resource: map[string]string{"service.name": "db", "host.name": "laptop"},
+			scopes:   map[string][]string{"humanlog": {"e"}},
+		},
+	}, groups)
+}
@@ -15,3 +15,7 @@ type BatchSink interface {
 	ReceiveBatch(ctx context.Context, evs []*typesv1.Log) error
 	Close(ctx context.Context) error
 }
+
+type SpanSink interface {
+	ReceiveSpan(ctx context.Context, span *typesv1.Span) error
+}
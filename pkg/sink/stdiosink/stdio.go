@@ -16,6 +16,7 @@ import (
 	"github.com/humanlogio/humanlog/internal/logqleval"
 	"github.com/humanlogio/humanlog/pkg/sink"
 	"github.com/ryanuber/go-glob"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
 )
 
 var (
@@ -144,7 +145,10 @@ func StdioOptsFrom(cfg *typesv1.FormatConfig) (StdioOpts, []error) {
 	return opts, errs
 }
 
-var _ sink.Sink = (*Stdio)(nil)
+var (
+	_ sink.Sink     = (*Stdio)(nil)
+	_ sink.SpanSink = (*Stdio)(nil)
+)
 
 func NewStdio(w io.Writer, opts StdioOpts) (*Stdio, error) {
 	rd := lipgloss.NewRenderer(w)
@@ -211,24 +215,12 @@ func (std *Stdio) ReceiveWithPostProcess(ctx context.Context, ev *typesv1.Log, p
 	} else {
 		msg = logtheme.Msg.Render(ev.Body)
 	}
-
-	lvl := strings.ToUpper(ev.SeverityText)[:min(4, len(ev.SeverityText))]
-	var level string
-	switch strings.ToLower(ev.SeverityText) {
-	case "debug":
-		level = logtheme.DebugLevel.Render(lvl)
-	case "info":
-		level = logtheme.InfoLevel.Render(lvl)
-	case "warn", "warning":
-		level = logtheme.WarnLevel.Render(lvl)
-	case "error":
-		level = logtheme.ErrorLevel.Render(lvl)
-	case "fatal", "panic":
-		level = logtheme.FatalLevel.Render(lvl)
-	default:
-		level = logtheme.UnknownLevel.Render(lvl)
+	if traceID := shortTraceID(ev.TraceId); traceID != "" {
+		msg = std.theme.Spans.TraceId.Render(traceID) + " " + msg
 	}
 
+	level := std.renderLevel(ev)
+
 	var ts time.Time
 	hasTimestamp := ev.Timestamp != nil
 	if hasTimestamp {
@@ -263,6 +255,14 @@ func (std *Stdio) ReceiveWithPostProcess(ctx context.Context, ev *typesv1.Log, p
 
 	buf.Write(eol[:])
 
+	if stack, ok := stacktrace(ev); ok && std.opts.shouldShowKey(string(semconv.ExceptionStacktraceKey)) {
+		for _, line := range strings.Split(stack, "\n") {
+			buf.WriteString(stackIndent)
+			buf.WriteString(logtheme.Val.Render(line))
+			buf.Write(eol[:])
+		}
+	}
+
 	if _, err := buf.WriteTo(std.w); err != nil {
 		return err
 	}
@@ -309,6 +309,63 @@ func (std *Stdio) ReceiveWithPostProcess(ctx context.Context, ev *typesv1.Log, p
 	return nil
 }
 
+// severityLabels name the ranges of OpenTelemetry severity numbers,
+// from TRACE to FATAL.
+var severityLabels = [...]string{"TRAC", "DEBU", "INFO", "WARN", "ERRO", "FATA"}
+
+// shortTraceID is enough of a trace ID to tell the logs of concurrent
+// traces apart. It's the end of the ID, as 64 bits IDs are padded with
+// zeros and the IDs of X-Ray begin with a timestamp.
+func shortTraceID(id *typesv1.TraceID) string {
+	if len(id.GetRaw()) == 0 {
+		return ""
+	}
+	h := typesv1.TraceIDToHex(id)
+	return h[len(h)-8:]
+}
+
+// renderLevel renders the level of `ev` by its severity number, and by
+// its text when it has none.
+func (std *Stdio) renderLevel(ev *typesv1.Log) string {
+	logtheme := std.theme.Logs
+	n := ev.SeverityNumber
+	if n < 1 || n > 24 {
+		lvl := strings.ToUpper(ev.SeverityText)[:min(4, len(ev.SeverityText))]
+		switch strings.ToLower(ev.SeverityText) {
+		case "debug":
+			return logtheme.DebugLevel.Render(lvl)
+		case "info":
+			return logtheme.InfoLevel.Render(lvl)
+		case "warn", "warning":
+			return logtheme.WarnLevel.Render(lvl)
+		case "error":
+			return logtheme.ErrorLevel.Render(lvl)
+		case "fatal", "panic":
+			return logtheme.FatalLevel.Render(lvl)
+		default:
+			return logtheme.UnknownLevel.Render(lvl)
+		}
+	}
+	lvl := severityLabels[(n-1)/4]
+	switch (n - 1) / 4 {
+	case 0, 1:
+		return logtheme.DebugLevel.Render(lvl)
+	case 2:
+		return logtheme.InfoLevel.Render(lvl)
+	case 3:
+		return logtheme.WarnLevel.Render(lvl)
+	case 4:
+		return logtheme.ErrorLevel.Render(lvl)
+	default:
+		// panics are as severe as other fatal levels, but stand out
+		switch strings.ToLower(ev.SeverityText) {
+		case "panic", "dpanic":
+			return logtheme.PanicLevel.Render("PANI")
+		}
+		return logtheme.FatalLevel.Render(lvl)
+	}
+}
+
 func (std *Stdio) ReceiveSpan(ctx context.Context, span *typesv1.Span) error {
 	spantheme := std.theme.Spans
 	buf := bytes.NewBuffer(nil)
@@ -543,7 +600,7 @@ func toString(value *typesv1.Val) (string, error) {
 	case time.Time:
 		return t.Format(time.RFC3339Nano), nil
 	case time.Duration:
-		return t.String(), nil
+		return humanDuration(t), nil
 	case map[string]any:
 		v, err := json.Marshal(t)
 		return string(v), err
@@ -554,6 +611,22 @@ func toString(value *typesv1.Val) (string, error) {
 	}
 }
 
+// humanDuration prints `d` without more precision than a reader cares
+// about, like `1.235s` rather than `1.234567891s`.
+func humanDuration(d time.Duration) string {
+	abs := d.Abs()
+	switch {
+	case abs >= time.Minute:
+		return d.Round(time.Second).String()
+	case abs >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case abs >= time.Millisecond:
+		return d.Round(time.Microsecond).String()
+	default:
+		return d.String()
+	}
+}
+
 func put(ref *map[string]string, key string, value any) {
 	switch t := value.(type) {
 	case string:
@@ -567,7 +640,7 @@ func put(ref *map[string]string, key string, value any) {
 	case time.Time:
 		(*ref)[key] = t.Format(time.RFC3339Nano)
 	case time.Duration:
-		(*ref)[key] = t.String()
+		(*ref)[key] = humanDuration(t)
 	case map[string]any:
 		for k, v := range t {
 			put(ref, key+"."+k, v)
@@ -594,15 +667,10 @@ func (std *Stdio) joinKVs(ev *typesv1.Log, sep string) []string {
 	}
 
 	kv := make([]string, 0, n)
-	appendAttr := func(pair *typesv1.KV) {
-		k, v := pair.Key, pair.Value
+	appendKV := func(k, w string) {
 		if !std.opts.shouldShowKey(k) {
 			return
 		}
-		w, err := toString(v)
-		if err != nil {
-			return
-		}
 
 		if skipUnchanged {
 			if lastV, ok := std.lastKVs[k]; ok && lastV == w && !std.opts.shouldShowUnchanged(k) {
@@ -620,7 +688,39 @@ func (std *Stdio) joinKVs(ev *typesv1.Log, sep string) []string {
 		vstr = std.theme.Logs.Val.Render(vstr)
 		kv = append(kv, kstr+sep+vstr)
 	}
+	appendAttr := func(pair *typesv1.KV) {
+		k, v := pair.Key, pair.Value
+		if v.GetObj() != nil || v.GetArr() != nil {
+			// nested values are shown flattened, like `a.b.0=c`
+			value, err := logqleval.ResolveVal(v, logqleval.MakeFlatGoMap, logqleval.MakeFlatMapGoSlice)
+			if err != nil {
+				return
+			}
+			flat := make(map[string]string)
+			put(&flat, k, value)
+			for fk, fw := range flat {
+				appendKV(fk, fw)
+			}
+			return
+		}
+		w, err := toString(v)
+		if err != nil {
+			return
+		}
+		appendKV(k, w)
+	}
 	for _, pair := range ev.Attributes {
+		if pair.Key == string(semconv.ExceptionStacktraceKey) {
+			// printed as a block below the line
+			continue
+		}
+		appendAttr(pair)
+	}
+	// where the event comes from is shown like any other attribute
+	for _, pair := range ev.Resource.GetAttributes() {
+		appendAttr(pair)
+	}
+	for _, pair := range ev.Scope.GetAttributes() {
 		appendAttr(pair)
 	}
 
@@ -633,6 +733,22 @@ func (std *Stdio) joinKVs(ev *typesv1.Log, sep string) []string {
 	return kv
 }
 
+const stackIndent = "    "
+
+// stacktrace returns the multi-line stack trace attached to the event, if
+// any.
+func stacktrace(ev *typesv1.Log) (string, bool) {
+	for _, kv := range ev.Attributes {
+		if kv.Key != string(semconv.ExceptionStacktraceKey) {
+			continue
+		}
+		if s := kv.Value.GetStr(); s != "" {
+			return s, true
+		}
+	}
+	return "", false
+}
+
 func (opts *StdioOpts) shouldShowKey(key string) bool {
 	if len(opts.Keep) != 0 {
 		for _, keep := range opts.Keep {
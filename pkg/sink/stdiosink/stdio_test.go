@@ -1,12 +1,15 @@
 package stdiosink
 
 import (
+	"bytes"
+	"context"
 	"testing"
 	"time"
 
 	typesv1 "github.com/minitape/api/go/types/v1"
 	"github.com/humanlogio/humanlog/internal/logqleval"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestPutKV(t *testing.T) {
@@ -60,6 +63,23 @@ func TestPutKV(t *testing.T) {
 				"root.g.6":   "2024-12-13T19:36:00Z",
 			},
 		},
+		{
+			name: "durations are rounded",
+			args: typesv1.KeyVal("root", typesv1.ValObj(
+				typesv1.KeyVal("a", typesv1.ValDuration(250*time.Millisecond)),
+				typesv1.KeyVal("b", typesv1.ValDuration(1234567891*time.Nanosecond)),
+				typesv1.KeyVal("c", typesv1.ValDuration(90*time.Second+400*time.Millisecond)),
+				typesv1.KeyVal("d", typesv1.ValDuration(1234567*time.Nanosecond)),
+				typesv1.KeyVal("e", typesv1.ValDuration(953*time.Nanosecond)),
+			)),
+			want: map[string]string{
+				"root.a": "250ms",
+				"root.b": "1.235s",
+				"root.c": "1m30s",
+				"root.d": "1.235ms",
+				"root.e": "953ns",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -76,3 +96,112 @@ func TestPutKV(t *testing.T) {
 		})
 	}
 }
+
+func TestReceiveStacktrace(t *testing.T) {
+	opts := DefaultStdioOpts
+	opts.ColorMode = Disable
+	opts.TimeZone = time.UTC
+	buf := bytes.NewBuffer(nil)
+	std, err := NewStdio(buf, opts)
+	require.NoError(t, err)
+
+	ev := &typesv1.Log{
+		Timestamp:    timestamppb.New(time.Date(2024, 10, 11, 15, 25, 6, 0, time.UTC)),
+		SeverityText: "error",
+		Body:         "request failed",
+		Attributes: []*typesv1.KV{
+			typesv1.KeyVal("user", typesv1.ValStr("bob")),
+			typesv1.KeyVal("exception.stacktrace", typesv1.ValStr("java.lang.IllegalStateException: boom\n\tat com.example.Foo.bar(Foo.java:42)")),
+		},
+	}
+	require.NoError(t, std.Receive(context.Background(), ev))
+
+	want := "Oct 11 15:25:06 |ERRO| request failed user=bob\n" +
+		"    java.lang.IllegalStateException: boom\n" +
+		"        at com.example.Foo.bar(Foo.java:42)\n"
+	require.Equal(t, want, buf.String())
+}
+
+func TestReceiveNestedValues(t *testing.T) {
+	opts := DefaultStdioOpts
+	opts.ColorMode = Disable
+	opts.TimeZone = time.UTC
+	buf := bytes.NewBuffer(nil)
+	std, err := NewStdio(buf, opts)
+	require.NoError(t, err)
+
+	ev := &typesv1.Log{
+		Timestamp:    timestamppb.New(time.Date(2024, 10, 11, 15, 25, 6, 0, time.UTC)),
+		SeverityText: "info",
+		Body:         "request",
+		Attributes: []*typesv1.KV{
+			typesv1.KeyVal("req", typesv1.ValObj(
+				typesv1.KeyVal("method", typesv1.ValStr("GET")),
+				typesv1.KeyVal("tags", typesv1.ValArr(typesv1.ValStr("a"), typesv1.ValI64(2))),
+			)),
+		},
+	}
+	require.NoError(t, std.Receive(context.Background(), ev))
+
+	want := "Oct 11 15:25:06 |INFO| request req.tags.0=a req.tags.1=2 req.method=GET\n"
+	require.Equal(t, want, buf.String())
+}
+
+func TestReceiveSeverity(t *testing.T) {
+	tests := []struct {
+		text   string
+		number uint32
+		want   string
+	}{
+		{text: "info", number: 9, want: "INFO"},
+		{text: "notice", number: 10, want: "INFO"},
+		{text: "trace", number: 1, want: "TRAC"},
+		{text: "critical", number: 21, want: "FATA"},
+		{text: "panic", number: 21, want: "PANI"},
+		{text: "custom", number: 22, want: "FATA"},
+		{text: "W", number: 13, want: "WARN"},
+		{text: "3", number: 17, want: "ERRO"},
+		{number: 17, want: "ERRO"},
+		{text: "whatever", want: "WHAT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			opts := DefaultStdioOpts
+			opts.ColorMode = Disable
+			opts.TimeZone = time.UTC
+			buf := bytes.NewBuffer(nil)
+			std, err := NewStdio(buf, opts)
+			require.NoError(t, err)
+
+			ev := &typesv1.Log{
+				Timestamp:      timestamppb.New(time.Date(2024, 10, 11, 15, 25, 6, 0, time.UTC)),
+				SeverityText:   tt.text,
+				SeverityNumber: tt.number,
+				Body:           "hello",
+			}
+			require.NoError(t, std.Receive(context.Background(), ev))
+			require.Equal(t, "Oct 11 15:25:06 |"+tt.want+"| hello \n", buf.String())
+		})
+	}
+}
+
+func TestReceiveTraceID(t *testing.T) {
+	opts := DefaultStdioOpts
+	opts.ColorMode = Disable
+	opts.TimeZone = time.UTC
+	buf := bytes.NewBuffer(nil)
+	std, err := NewStdio(buf, opts)
+	require.NoError(t, err)
+
+	traceID, err := typesv1.TraceIDFromHex(nil, "4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	ev := &typesv1.Log{
+		Timestamp:      timestamppb.New(time.Date(2024, 10, 11, 15, 25, 6, 0, time.UTC)),
+		SeverityText:   "info",
+		SeverityNumber: 9,
+		Body:           "hello",
+		TraceId:        traceID,
+	}
+	require.NoError(t, std.Receive(context.Background(), ev))
+	require.Equal(t, "Oct 11 15:25:06 |INFO| 0e0e4736 hello \n", buf.String())
+}
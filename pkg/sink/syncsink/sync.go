@@ -0,0 +1,34 @@
+package syncsink
+
+import (
+	"context"
+	"sync"
+
+	"github.com/humanlogio/humanlog/pkg/sink"
+	typesv1 "github.com/minitape/api/go/types/v1"
+)
+
+var _ sink.Sink = (*SyncSink)(nil)
+
+// SyncSink serializes calls to a sink, so that many scanners can share
+// a sink that isn't safe for concurrent use.
+type SyncSink struct {
+	mu   sync.Mutex
+	next sink.Sink
+}
+
+func NewSyncSink(next sink.Sink) *SyncSink {
+	return &SyncSink{next: next}
+}
+
+func (sn *SyncSink) Receive(ctx context.Context, ev *typesv1.Log) error {
+	sn.mu.Lock()
+	defer sn.mu.Unlock()
+	return sn.next.Receive(ctx, ev)
+}
+
+func (sn *SyncSink) Close(ctx context.Context) error {
+	sn.mu.Lock()
+	defer sn.mu.Unlock()
+	return sn.next.Close(ctx)
+}
@@ -0,0 +1,101 @@
+package humanlog
+
+import (
+	"slices"
+
+	typesv1 "github.com/minitape/api/go/types/v1"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+)
+
+// KeepAttribute, as the resource attribute of a promotion, keeps the
+// field as an attribute of the event.
+const KeepAttribute = "-"
+
+// ResourcePromotion moves an attribute that tells where an event comes
+// from, like `hostname`, to the resource of the event.
+type ResourcePromotion struct {
+	// Field is the name of the attribute.
+	Field string
+	// As is the resource attribute it becomes, Field itself when empty.
+	// KeepAttribute leaves the field alone, overriding the promotions
+	// that follow.
+	As string
+}
+
+// DefaultResourcePromotions are the fields humanlog knows to be about
+// resources.
+var DefaultResourcePromotions = func() []*ResourcePromotion {
+	promote := func(as string, fields ...string) []*ResourcePromotion {
+		out := make([]*ResourcePromotion, 0, len(fields))
+		for _, field := range fields {
+			out = append(out, &ResourcePromotion{Field: field, As: as})
+		}
+		return out
+	}
+	return slices.Concat(
+		// `service` is recorded by the docker-compose prefix extractor
+		promote(string(semconv.ServiceNameKey), "service.name", "service", "service_name", "serviceName"),
+		promote(string(semconv.ServiceNamespaceKey), "service.namespace"),
+		promote(string(semconv.ServiceVersionKey), "service.version"),
+		promote(string(semconv.ServiceInstanceIDKey), "service.instance.id"),
+		promote(string(semconv.HostNameKey), "host.name", "hostname"),
+		promote(string(semconv.K8SPodNameKey), "k8s.pod.name", "pod", "pod_name"),
+		promote(string(semconv.K8SNamespaceNameKey), "k8s.namespace.name"),
+		promote(string(semconv.K8SContainerNameKey), "k8s.container.name"),
+		promote(string(semconv.DeploymentEnvironmentNameKey), "deployment.environment.name", "env", "environment"),
+	)
+}
+
+// promoteResource moves the attributes of `ev` that are about its
+// resource to it, as told by the `ResourcePromotions` handler option,
+// and names its service. Attributes already in the resource win.
+func promoteResource(ev *typesv1.Log, opts *HandlerOptions) {
+	var promoted []*typesv1.KV
+	attrs := ev.Attributes[:0]
+	for _, kv := range ev.Attributes {
+		key, ok := resourceKeyOf(kv, opts.ResourcePromotions)
+		if !ok || hasKey(ev.Resource.GetAttributes(), key) || hasKey(promoted, key) {
+			attrs = append(attrs, kv)
+			continue
+		}
+		promoted = append(promoted, typesv1.KeyVal(key, kv.Value))
+	}
+	ev.Attributes = attrs
+	if len(promoted) > 0 {
+		kvs := slices.Concat(ev.Resource.GetAttributes(), promoted)
+		ev.Resource = typesv1.NewResource(ev.Resource.GetSchemaUrl(), kvs)
+	}
+	if ev.ServiceName == "" && ev.Resource != nil {
+		ev.ServiceName = ev.Resource.LookupServiceName()
+	}
+}
+
+// resourceKeyOf tells the resource attribute that `kv` is promoted to,
+// if any. Only scalar values are promoted.
+func resourceKeyOf(kv *typesv1.KV, promotions []*ResourcePromotion) (string, bool) {
+	if kv.Value.GetObj() != nil || kv.Value.GetArr() != nil || kv.Value.GetMap() != nil {
+		return "", false
+	}
+	for _, promotion := range promotions {
+		if !fieldsEqualAllString(kv.Key, promotion.Field) {
+			continue
+		}
+		switch promotion.As {
+		case KeepAttribute:
+			return "", false
+		case "":
+			return promotion.Field, true
+		}
+		return promotion.As, true
+	}
+	return "", false
+}
+
+func hasKey(kvs []*typesv1.KV, key string) bool {
+	for _, kv := range kvs {
+		if kv.Key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package humanlog
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"github.com/humanlogio/humanlog/pkg/sink/bufsink"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestResourcePromotion(t *testing.T) {
+	now := time.Date(2024, 10, 11, 15, 25, 6, 0, time.UTC)
+	tests := []struct {
+		name       string
+		input      string
+		promotions []*ResourcePromotion
+		want       *typesv1.Log
+	}{
+		{
+			name:  "usual fields",
+			input: `{"msg":"hello","hostname":"web-1","env":"prod","service.name":"api","user":42}`,
+			want: &typesv1.Log{
+				Body:        "hello",
+				ServiceName: "api",
+				Resource: typesv1.NewResource("", []*typesv1.KV{
+					typesv1.KeyVal("host.name", typesv1.ValStr("web-1")),
+					typesv1.KeyVal("deployment.environment.name", typesv1.ValStr("prod")),
+					typesv1.KeyVal("service.name", typesv1.ValStr("api")),
+				}),
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("user", typesv1.ValI64(42)),
+				},
+			},
+		},
+		{
+			name:  "docker-compose service",
+			input: `web_1  | {"level":"info","msg":"hello"}`,
+			want: &typesv1.Log{
+				Body:           "hello",
+				SeverityText:   "info",
+				SeverityNumber: 9,
+				ServiceName:    "web_1",
+				Resource: typesv1.NewResource("", []*typesv1.KV{
+					typesv1.KeyVal("service.name", typesv1.ValStr("web_1")),
+				}),
+			},
+		},
+		{
+			name:  "resource of the prefix wins",
+			input: `[pod/web-7d4b9c8f6-x2x9z/nginx] {"level":"info","msg":"hello","pod":"other"}`,
+			want: &typesv1.Log{
+				Body:           "hello",
+				SeverityText:   "info",
+				SeverityNumber: 9,
+				Resource: typesv1.NewResource("", []*typesv1.KV{
+					typesv1.KeyVal("k8s.pod.name", typesv1.ValStr("web-7d4b9c8f6-x2x9z")),
+					typesv1.KeyVal("k8s.container.name", typesv1.ValStr("nginx")),
+				}),
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("pod", typesv1.ValStr("other")),
+				},
+			},
+		},
+		{
+			name:  "first promotion applies",
+			input: `{"msg":"hello","env":"prod","dc":"eu-west-1","service":{"name":"api"}}`,
+			promotions: append([]*ResourcePromotion{
+				{Field: "env", As: KeepAttribute},
+				{Field: "dc", As: "cloud.region"},
+			}, DefaultResourcePromotions()...),
+			want: &typesv1.Log{
+				Body:        "hello",
+				ServiceName: "api",
+				Resource: typesv1.NewResource("", []*typesv1.KV{
+					typesv1.KeyVal("cloud.region", typesv1.ValStr("eu-west-1")),
+					typesv1.KeyVal("service.name", typesv1.ValStr("api")),
+				}),
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("env", typesv1.ValStr("prod")),
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.newULID = func() *typesv1.ULID { return nil }
+			opts.timeNow = func() time.Time { return now }
+			opts.ResourcePromotions = DefaultResourcePromotions()
+			if tt.promotions != nil {
+				opts.ResourcePromotions = tt.promotions
+			}
+
+			sink := bufsink.NewSizedBufferedSink(100, nil)
+			require.NoError(t, Scan(context.Background(), strings.NewReader(tt.input), sink, opts))
+
+			require.Len(t, sink.Buffered, 1)
+			tt.want.Raw = []byte(tt.input)
+			tt.want.ObservedTimestamp = timestamppb.New(now)
+			diff := cmp.Diff(tt.want, sink.Buffered[0], protocmp.Transform())
+			require.Empty(t, diff)
+		})
+	}
+}
@@ -6,9 +6,11 @@ import (
 	"context"
 	"errors"
 	"io"
+	"slices"
+	"time"
 
-	typesv1 "github.com/minitape/api/go/types/v1"
 	"github.com/humanlogio/humanlog/pkg/sink"
+	typesv1 "github.com/minitape/api/go/types/v1"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -19,56 +21,70 @@ const maxBufferSize = 1024 * 1024
 // prettification.
 func Scan(ctx context.Context, src io.Reader, sink sink.Sink, opts *HandlerOptions) error {
 
-	in := bufio.NewScanner(src)
-	in.Buffer(make([]byte, 0, maxBufferSize), maxBufferSize)
-	in.Split(bufio.ScanLines)
-
-	var line uint64
+	in := newLineScanner(src)
+	parse := newLineParser(opts)
 
-	logfmtEntry := LogfmtHandler{Opts: opts}
-	jsonEntry := JSONHandler{Opts: opts}
+	if opts.Multiline != nil {
+		return scanMultiline(ctx, in, sink, opts.Multiline, parse)
+	}
 
 	ev := new(typesv1.Log)
+	for in.Scan() {
+		if !parse(in.Bytes(), ev) {
+			continue
+		}
 
-	handlers := []func([]byte, *typesv1.Log) bool{
-		jsonEntry.TryHandle,
-		logfmtEntry.TryHandle,
-		func(lineData []byte, data *typesv1.Log) bool {
-			return tryDockerComposePrefix(lineData, data, &jsonEntry)
-		},
-		func(lineData []byte, data *typesv1.Log) bool {
-			return tryDockerComposePrefix(lineData, data, &logfmtEntry)
-		},
-		func(lineData []byte, data *typesv1.Log) bool {
-			return tryZapDevPrefix(lineData, data, &jsonEntry)
-		},
+		if err := sink.Receive(ctx, ev); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
 	}
 
-	skipNextScan := false
-	for {
+	select {
+	case <-ctx.Done():
+		return nil
+	default:
+	}
 
-		if !in.Scan() {
-			err := in.Err()
-			if err == nil || errors.Is(err, io.EOF) {
-				break
-			}
-			if errors.Is(err, bufio.ErrTooLong) {
-				in = bufio.NewScanner(src)
-				in.Buffer(make([]byte, 0, maxBufferSize), maxBufferSize)
-				in.Split(bufio.ScanLines)
-				skipNextScan = true
-				continue
-			}
-			break
+	return in.Err()
+}
+
+// newLineParser returns a func that parses a line into `ev`. It returns
+// false if the line is only part of an event, which will be completed by
+// the lines that follow.
+func newLineParser(opts *HandlerOptions) func(lineData []byte, ev *typesv1.Log) bool {
+	// the field lists are reordered as fields are found, and the options
+	// can be shared by parsers running at the same time
+	own := *opts
+	own.TimeFields = slices.Clone(opts.TimeFields)
+	own.MessageFields = slices.Clone(opts.MessageFields)
+	own.LevelFields = slices.Clone(opts.LevelFields)
+	opts = &own
+
+	handle := newHandlerChain(opts)
+	var expander *fieldExpander
+	if len(opts.Expand) > 0 {
+		expander = newFieldExpander(opts)
+	}
+	// finish completes the events parsed by any handler
+	finish := func(ev *typesv1.Log) bool {
+		if !ev.IsStructured() {
+			return true
 		}
-		if skipNextScan {
-			skipNextScan = false
-			continue
+		if expander != nil {
+			expander.expand(ev)
 		}
+		normalizeSeverity(ev, opts)
+		extractTraceContext(ev, opts)
+		promoteResource(ev, opts)
+		return true
+	}
 
-		line++
-		lineData := in.Bytes()
-
+	return func(lineData []byte, ev *typesv1.Log) bool {
 		ev.Reset()
 		ev.Raw = lineData
 		ev.Ulid = opts.newULID()
@@ -77,33 +93,158 @@ func Scan(ctx context.Context, src io.Reader, sink sink.Sink, opts *HandlerOptio
 		// remove that pesky syslog crap
 		lineData = bytes.TrimPrefix(lineData, []byte("@cee: "))
 
-	handled_line:
-		for i, tryHandler := range handlers {
-			if tryHandler(lineData, ev) {
-				if dynamicReordering && i != 0 {
-					handlers = moveToFront(i, handlers)
+		if handled, complete := handle(lineData, ev); handled && !complete {
+			return false
+		}
+		return finish(ev)
+	}
+}
+
+// newHandlerChain returns a func that handles a line with the first
+// handler that recognizes it, telling if the line completed an event.
+// Handlers that recognize lines are moved first, after the pinned ones,
+// as the next lines are probably in the same format.
+func newHandlerChain(opts *HandlerOptions) func(lineData []byte, ev *typesv1.Log) (handled, complete bool) {
+	handlers := chainHandlers(opts)
+	pinned := 0
+	for pinned < len(handlers) && handlers[pinned].pinned {
+		pinned++
+	}
+
+	return func(lineData []byte, ev *typesv1.Log) (bool, bool) {
+		for i, handler := range handlers {
+			handled, complete := handler.handle(lineData, ev)
+			if opts.explain.tried(handler.name, handled); handled {
+				if dynamicReordering && i > pinned {
+					moveToFront(i-pinned, handlers[pinned:])
 				}
-				break handled_line
+				return true, complete
 			}
 		}
+		return false, false
+	}
+}
 
-		if err := sink.Receive(ctx, ev); err != nil {
-			return err
+// scanMultiline is like the main loop of `Scan`, but holds on to each
+// structured event until the lines that follow it are known not to be
+// continuations of it. Since the next line might take a while to come,
+// lines are read in the background and a pending event gets flushed if
+// nothing comes for a bit.
+func scanMultiline(ctx context.Context, in *lineScanner, sink sink.Sink, opts *MultilineOptions, parse func([]byte, *typesv1.Log) bool) error {
+	linec := make(chan []byte)
+	go func() {
+		defer close(linec)
+		for in.Scan() {
+			select {
+			case linec <- bytes.Clone(in.Bytes()):
+			case <-ctx.Done():
+				return
+			}
 		}
+	}()
+
+	co := &coalescer{opts: opts}
+	flush := func() error {
+		if ev := co.release(); ev != nil {
+			return sink.Receive(ctx, ev)
+		}
+		return nil
+	}
+
+	flushTimer := time.NewTimer(opts.FlushAfter)
+	flushTimer.Stop()
+	defer flushTimer.Stop()
+
+	for {
 		select {
 		case <-ctx.Done():
-			return nil
-		default:
+			return flush()
+		case <-flushTimer.C:
+			if err := flush(); err != nil {
+				return err
+			}
+		case lineData, more := <-linec:
+			if !more {
+				if err := flush(); err != nil {
+					return err
+				}
+				if ctx.Err() != nil {
+					return nil
+				}
+				return in.Err()
+			}
+			if co.isContinuation(lineData) {
+				co.attach(lineData)
+				flushTimer.Reset(opts.FlushAfter)
+				continue
+			}
+			if err := flush(); err != nil {
+				return err
+			}
+			ev := new(typesv1.Log)
+			if !parse(lineData, ev) {
+				continue
+			}
+			if !ev.IsStructured() {
+				if err := sink.Receive(ctx, ev); err != nil {
+					return err
+				}
+				continue
+			}
+			co.hold(ev)
+			flushTimer.Reset(opts.FlushAfter)
 		}
 	}
+}
 
-	select {
-	case <-ctx.Done():
-		return nil
-	default:
+// lineScanner splits its input in lines, skipping over lines that are
+// too long to fit in its buffer.
+type lineScanner struct {
+	src          io.Reader
+	in           *bufio.Scanner
+	skipNextScan bool
+}
+
+func newLineScanner(src io.Reader) *lineScanner {
+	ls := &lineScanner{src: src}
+	ls.reset()
+	return ls
+}
+
+func (ls *lineScanner) reset() {
+	ls.in = bufio.NewScanner(ls.src)
+	ls.in.Buffer(make([]byte, 0, maxBufferSize), maxBufferSize)
+	ls.in.Split(bufio.ScanLines)
+}
+
+func (ls *lineScanner) Scan() bool {
+	for {
+		if !ls.in.Scan() {
+			err := ls.in.Err()
+			if err == nil || errors.Is(err, io.EOF) {
+				return false
+			}
+			if errors.Is(err, bufio.ErrTooLong) {
+				ls.reset()
+				ls.skipNextScan = true
+				continue
+			}
+			return false
+		}
+		if ls.skipNextScan {
+			ls.skipNextScan = false
+			continue
+		}
+		return true
 	}
+}
+
+func (ls *lineScanner) Bytes() []byte {
+	return ls.in.Bytes()
+}
 
-	switch err := in.Err(); err {
+func (ls *lineScanner) Err() error {
+	switch err := ls.in.Err(); err {
 	case nil, io.EOF:
 		return nil
 	default:
@@ -111,9 +252,12 @@ func Scan(ctx context.Context, src io.Reader, sink sink.Sink, opts *HandlerOptio
 	}
 }
 
-func checkEachUntilFound(fieldList []string, found func(string) bool) bool {
+// checkEachUntilFound looks for the first field of `fieldList`, the
+// handler option named `option`, that is `found`.
+func checkEachUntilFound(opts *HandlerOptions, option string, fieldList []string, found func(string) bool) bool {
 	for i, field := range fieldList {
 		if found(field) {
+			opts.explain.lookedUp(option, fieldList, field)
 			if dynamicReordering {
 				// the log stream probably will always be using this field
 				moveToFront(i, fieldList)
@@ -121,5 +265,6 @@ func checkEachUntilFound(fieldList []string, found func(string) bool) bool {
 			return true
 		}
 	}
+	opts.explain.lookedUp(option, fieldList, "")
 	return false
 }
@@ -43,7 +43,7 @@ key=value2 msg="i like turtle" key2=43`,
 					Body:              "i like turtle",
 					Attributes: []*typesv1.KV{
 						{Key: "key", Value: typesv1.ValStr("value2")},
-						{Key: "key2", Value: typesv1.ValStr("43")},
+						{Key: "key2", Value: typesv1.ValI64(43)},
 					},
 				},
 			},
@@ -70,7 +70,7 @@ time="2022/09/11 16:31:21.198136" do_all_electric_sheeps="dream"`,
 					Body:              "i like turtle",
 					Attributes: []*typesv1.KV{
 						{Key: "key", Value: typesv1.ValStr("value1")},
-						{Key: "key2", Value: typesv1.ValStr("42")},
+						{Key: "key2", Value: typesv1.ValI64(42)},
 					},
 				},
 				{
@@ -79,7 +79,7 @@ time="2022/09/11 16:31:21.198136" do_all_electric_sheeps="dream"`,
 					Body:              "i like turtle",
 					Attributes: []*typesv1.KV{
 						{Key: "key", Value: typesv1.ValStr("value2")},
-						{Key: "key2", Value: typesv1.ValStr("43")},
+						{Key: "key2", Value: typesv1.ValI64(43)},
 					},
 				},
 				{
@@ -109,6 +109,7 @@ time="2022/09/11 16:31:21.198136" do_all_electric_sheeps="dream"`,
 					ObservedTimestamp: timestamppb.New(now),
 					Timestamp:         timestamppb.New(time.Date(2025, 4, 1, 14, 56, 29, 432068824, time.UTC)),
 					SeverityText:      "error",
+					SeverityNumber:    17,
 					Raw:               []byte(`{"level":"error","ts":1743519389.4320688,"logger":"controller.CrdbCluster","msg":"action failed"}`),
 					Body:              "action failed",
 					Attributes: []*typesv1.KV{
@@ -119,6 +120,7 @@ time="2022/09/11 16:31:21.198136" do_all_electric_sheeps="dream"`,
 					ObservedTimestamp: timestamppb.New(now),
 					Timestamp:         timestamppb.New(time.Date(2025, 4, 1, 14, 56, 29, 432068824, time.UTC)),
 					SeverityText:      "error",
+					SeverityNumber:    17,
 					Raw:               []byte(`{"level":"error","ts":1743519389.4320688,"logger":"controller.CrdbCluster","msg":"action failed"}`),
 					Body:              "action failed",
 					Attributes: []*typesv1.KV{
@@ -233,6 +235,7 @@ func TestFlatteningNestedObjects_with_a_big_number(t *testing.T) {
 			Raw:               []byte(`{"time":"2024-10-29T16:45:54.384776Z","level":"DEBUG","source":{"function":"github.com/humanlogio/humanlog/internal/memstorage.(*MemStorageSink).firstMatch","file":"/Users/antoine/code/src/github.com/humanlogio/humanlog/internal/memstorage/memory.go","line":243},"msg":"first match found at index","storage":{"machine.id":5089,"session.id":1730187806608637000,"i":0}}`),
 			Timestamp:         timestamppb.New(time.Date(2024, 10, 29, 16, 45, 54, 384776000, time.UTC)),
 			SeverityText:      "DEBUG",
+			SeverityNumber:    5,
 			Body:              "first match found at index",
 			Attributes: []*typesv1.KV{
 				typesv1.KeyVal("source.function", typesv1.ValStr("github.com/humanlogio/humanlog/internal/memstorage.(*MemStorageSink).firstMatch")),
@@ -327,9 +330,10 @@ func TestFlatteningNestedObjects_with_arrays(t *testing.T) {
 			ObservedTimestamp: timestamppb.New(now),
 			Raw:               []byte(`{"time":"2024-12-05T06:40:35.247902137Z","level":"DEBUG","source":{"function":"main.realMain.func5.1","file":"github.com/humanlogio/apisvc/cmd/apisvc/server_cmd.go","line":407},"msg":"galaxycache peers updated","selfURI":"10.244.0.126:8083","peers":[{"ID":"10.244.0.126:8083","URI":"10.244.0.126:8083"},{"ID":"10.244.0.206:8083","URI":"10.244.0.206:8083"},{"ID":"10.244.1.150:8083","URI":"10.244.1.150:8083"}]}`),
 
-			Timestamp:    timestamppb.New(time.Date(2024, 12, 5, 6, 40, 35, 247902137, time.UTC)),
-			SeverityText: "DEBUG",
-			Body:         "galaxycache peers updated",
+			Timestamp:      timestamppb.New(time.Date(2024, 12, 5, 6, 40, 35, 247902137, time.UTC)),
+			SeverityText:   "DEBUG",
+			SeverityNumber: 5,
+			Body:           "galaxycache peers updated",
 			Attributes: []*typesv1.KV{
 				{
 					Key:   "selfURI",
@@ -419,6 +423,7 @@ func TestFlatteningNestedObjects_with_nested_arrays(t *testing.T) {
 			Raw:               []byte(`{"time":"2024-12-05T06:40:35.247902137Z","level":"DEBUG","msg":"galaxycache peers updated","peers":[[1,2,3],[4,5,6],[{"ID":"10.244.0.126:8083","URI":"10.244.0.126:8083"},{"ID":"10.244.0.206:8083","URI":"10.244.0.206:8083"},{"ID":"10.244.1.150:8083","URI":"10.244.1.150:8083"}]]}`),
 			Timestamp:         timestamppb.New(time.Date(2024, 12, 5, 6, 40, 35, 247902137, time.UTC)),
 			SeverityText:      "DEBUG",
+			SeverityNumber:    5,
 			Body:              "galaxycache peers updated",
 			Attributes: []*typesv1.KV{
 				{
@@ -523,3 +528,29 @@ func pjson(m proto.Message) string {
 	}
 	return string(o)
 }
+
+func TestScanConcurrently(t *testing.T) {
+	// the streams find their fields in different orders, making both
+	// reorder the field lists of the options they share
+	opts := DefaultOptions()
+	streams := []string{
+		strings.Repeat(`{"timestamp":"2024-10-11T15:25:06Z","lvl":"info","msg":"a"}`+"\n", 100),
+		strings.Repeat(`ts="2024-10-11 15:25:06" level=warn message=b`+"\n", 100),
+	}
+	sinks := make([]*bufsink.SizedBuffer, len(streams))
+	errs := make(chan error, len(streams))
+	for i, stream := range streams {
+		sinks[i] = bufsink.NewSizedBufferedSink(1000, nil)
+		go func() { errs <- Scan(context.Background(), strings.NewReader(stream), sinks[i], opts) }()
+	}
+	for range streams {
+		require.NoError(t, <-errs)
+	}
+	for i, want := range []string{"a", "b"} {
+		require.Len(t, sinks[i].Buffered, 100)
+		for _, ev := range sinks[i].Buffered {
+			require.Equal(t, want, ev.Body)
+			require.NotNil(t, ev.Timestamp)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package humanlog
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	typesv1 "github.com/minitape/api/go/types/v1"
+)
+
+// The first OpenTelemetry severity number of each range of levels. The
+// three numbers that follow each are finer levels, like INFO2.
+const (
+	SeverityTrace uint32 = 1
+	SeverityDebug uint32 = 5
+	SeverityInfo  uint32 = 9
+	SeverityWarn  uint32 = 13
+	SeverityError uint32 = 17
+	SeverityFatal uint32 = 21
+)
+
+// severityNames maps the level names of popular logging libraries, in
+// lowercase, to severity numbers.
+var severityNames = map[string]uint32{
+	"trace": SeverityTrace, "trc": SeverityTrace, "verbose": SeverityTrace, "vrb": SeverityTrace,
+	"finest": SeverityTrace, "finer": SeverityTrace + 2,
+
+	"debug": SeverityDebug, "dbg": SeverityDebug, "fine": SeverityDebug, "config": SeverityDebug + 2,
+
+	"info": SeverityInfo, "inf": SeverityInfo, "information": SeverityInfo, "informational": SeverityInfo,
+	"notice": SeverityInfo + 1,
+
+	"warn": SeverityWarn, "warning": SeverityWarn, "wrn": SeverityWarn,
+
+	"error": SeverityError, "err": SeverityError, "eror": SeverityError, "severe": SeverityError,
+
+	"fatal": SeverityFatal, "ftl": SeverityFatal, "crit": SeverityFatal, "critical": SeverityFatal,
+	"panic": SeverityFatal, "dpanic": SeverityFatal, "alert": SeverityFatal + 2,
+	"emerg": SeverityFatal + 3, "emergency": SeverityFatal + 3,
+
+	// the single letters of glog, Ruby and Android's logcat
+	"v": SeverityTrace, "d": SeverityDebug, "i": SeverityInfo, "w": SeverityWarn,
+	"e": SeverityError, "f": SeverityFatal, "a": SeverityFatal + 2,
+}
+
+// shortSeverityRe matches the short names of severity numbers that
+// OpenTelemetry uses, like `INFO2`.
+var shortSeverityRe = regexp.MustCompile(`^(trace|debug|info|warn|error|fatal)([1-4]?)$`)
+
+// ParseSeverityNumber parses a severity number, written as such or by
+// its short name, like `10` or `INFO2`.
+func ParseSeverityNumber(s string) (uint32, bool) {
+	if n, err := strconv.ParseUint(s, 10, 32); err == nil {
+		if n < 1 || n > 24 {
+			return 0, false
+		}
+		return uint32(n), true
+	}
+	m := shortSeverityRe.FindStringSubmatch(strings.ToLower(s))
+	if m == nil {
+		return 0, false
+	}
+	n := severityNames[m[1]]
+	if m[2] != "" {
+		n += uint32(m[2][0] - '1')
+	}
+	return n, true
+}
+
+// severityNumberOf tells the severity number of a level, looking it up
+// in `overrides`, keyed by lowercase names, first. Numeric levels are
+// syslog severities from 0 to 7, and bunyan or pino levels from 10 to 69.
+func severityNumberOf(level string, overrides map[string]uint32) (uint32, bool) {
+	lower := strings.ToLower(strings.TrimSpace(level))
+	if n, ok := overrides[lower]; ok {
+		return n, true
+	}
+	if n, ok := severityNames[lower]; ok {
+		return n, true
+	}
+	if n, err := strconv.Atoi(lower); err == nil {
+		switch {
+		case n >= 0 && n < len(syslogSeverities):
+			return syslogSeverities[n].number, true
+		case n >= 10 && n < 70:
+			return SeverityTrace + uint32(n/10-1)*4, true
+		}
+		return 0, false
+	}
+	return ParseSeverityNumber(lower)
+}
+
+// normalizeSeverity sets the severity number of events that only have a
+// level name.
+func normalizeSeverity(ev *typesv1.Log, opts *HandlerOptions) {
+	if ev.SeverityNumber != 0 || ev.SeverityText == "" {
+		return
+	}
+	if n, ok := severityNumberOf(ev.SeverityText, opts.Severities); ok {
+		ev.SeverityNumber = n
+	}
+}
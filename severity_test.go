@@ -0,0 +1,72 @@
+package humanlog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeverityNumberOf(t *testing.T) {
+	tests := []struct {
+		level     string
+		overrides map[string]uint32
+		want      uint32
+		wantOK    bool
+	}{
+		{level: "info", want: SeverityInfo, wantOK: true},
+		{level: "WARNING", want: SeverityWarn, wantOK: true},
+		{level: "trace", want: SeverityTrace, wantOK: true},
+		{level: "notice", want: SeverityInfo + 1, wantOK: true},
+		{level: "critical", want: SeverityFatal, wantOK: true},
+		{level: "emerg", want: 24, wantOK: true},
+		{level: "panic", want: SeverityFatal, wantOK: true},
+		{level: "SEVERE", want: SeverityError, wantOK: true},
+		{level: "finer", want: 3, wantOK: true},
+		{level: "I", want: SeverityInfo, wantOK: true},
+		{level: "E", want: SeverityError, wantOK: true},
+		{level: "INFO3", want: 11, wantOK: true},
+		{level: "error4", want: 20, wantOK: true},
+		// syslog
+		{level: "3", want: SeverityError, wantOK: true},
+		{level: "7", want: SeverityDebug, wantOK: true},
+		// bunyan and pino
+		{level: "30", want: SeverityInfo, wantOK: true},
+		{level: "35", want: SeverityInfo, wantOK: true},
+		{level: "60", want: SeverityFatal, wantOK: true},
+		{level: "AUDIT", overrides: map[string]uint32{"audit": 10}, want: 10, wantOK: true},
+		{level: "info", overrides: map[string]uint32{"info": 12}, want: 12, wantOK: true},
+		{level: "8"},
+		{level: "100"},
+		{level: "info5"},
+		{level: "???"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			got, ok := severityNumberOf(tt.level, tt.overrides)
+			require.Equal(t, tt.wantOK, ok)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseSeverityNumber(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   uint32
+		wantOK bool
+	}{
+		{in: "10", want: 10, wantOK: true},
+		{in: "INFO2", want: 10, wantOK: true},
+		{in: "fatal", want: SeverityFatal, wantOK: true},
+		{in: "0"},
+		{in: "25"},
+		{in: "notice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := ParseSeverityNumber(tt.in)
+			require.Equal(t, tt.wantOK, ok)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
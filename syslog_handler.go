@@ -0,0 +1,223 @@
+package humanlog
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"time"
+
+	typesv1 "github.com/minitape/api/go/types/v1"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// RFC 5424 lines are made of the following, separated by a space. The
+// nil value for a field is a dash.
+//  1. PRI in angle brackets, directly followed by the VERSION
+//  2. TIMESTAMP in RFC 3339
+//  3. HOSTNAME
+//  4. APP-NAME
+//  5. PROCID
+//  6. MSGID
+//  7. STRUCTURED-DATA, and the MSG, which are parsed separately
+var rfc5424HeaderRe = regexp.MustCompile(`^<(?P<pri>\d{1,3})>(?P<version>[1-9]\d?) (?P<timestamp>\S+) (?P<hostname>\S+) (?P<app_name>\S+) (?P<procid>\S+) (?P<msgid>\S+) `)
+
+// RFC 3164 lines (BSD syslog) are made of:
+//  1. an optional PRI in angle brackets, absent from files written by
+//     syslog daemons
+//  2. the TIMESTAMP, as `Jan _2 15:04:05`, or RFC 3339 for some daemons
+//  3. HOSTNAME
+//  4. the TAG, usually the program name, and its optional PID in brackets
+//  5. a colon, and the MSG
+var rfc3164Re = regexp.MustCompile(`^(?:<(?P<pri>\d{1,3})>)?(?P<timestamp>[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}|\d{4}-\d{2}-\d{2}T\S+) (?P<hostname>\S+) (?P<tag>[^\s:\[]+)(?:\[(?P<pid>[^\]]*)\])?: ?(?P<msg>.*)$`)
+
+const syslogNil = "-"
+
+var syslogFacilities = [...]string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// syslogSeverities maps syslog severities to the level names humanlog
+// understands, and to OpenTelemetry severity numbers.
+var syslogSeverities = [...]struct {
+	text   string
+	number uint32
+}{
+	{"fatal", 21}, // emerg
+	{"fatal", 21}, // alert
+	{"fatal", 21}, // crit
+	{"error", 17}, // err
+	{"warn", 13},  // warning
+	{"info", 10},  // notice
+	{"info", 9},   // info
+	{"debug", 5},  // debug
+}
+
+func trySyslog(d []byte, ev *typesv1.Log, opts *HandlerOptions, jsonHandler *JSONHandler, logfmtHandler *LogfmtHandler) bool {
+	if matches := rfc5424HeaderRe.FindSubmatch(d); matches != nil {
+		sdAttrs, msg, ok := parseStructuredData(d[len(matches[0]):])
+		if !ok {
+			return false
+		}
+		var ts time.Time
+		if string(matches[3]) != syslogNil {
+			var err error
+			ts, err = time.Parse(time.RFC3339Nano, string(matches[3]))
+			if err != nil {
+				return false
+			}
+		}
+		handleSyslogMsg(msg, ev, jsonHandler, logfmtHandler)
+		applySyslogHeader(ev, matches[1], ts, matches[4], matches[5], matches[6])
+		if msgid := string(matches[7]); msgid != syslogNil {
+			ev.Attributes = append(ev.Attributes, typesv1.KeyVal("syslog.msgid", typesv1.ValStr(msgid)))
+		}
+		ev.Attributes = append(ev.Attributes, sdAttrs...)
+		return true
+	}
+	if matches := rfc3164Re.FindSubmatch(d); matches != nil {
+		// without PRI, only trust the traditional timestamp, or any
+		// `<time> <word> <word>: <text>` line would look like syslog
+		ts, ok := parseRFC3164Time(string(matches[2]), opts.timeNow(), len(matches[1]) > 0)
+		if !ok {
+			return false
+		}
+		handleSyslogMsg(matches[6], ev, jsonHandler, logfmtHandler)
+		pid := matches[5]
+		if len(pid) == 0 {
+			pid = []byte(syslogNil)
+		}
+		applySyslogHeader(ev, matches[1], ts, matches[3], matches[4], pid)
+		return true
+	}
+	return false
+}
+
+// handleSyslogMsg parses the MSG part of a syslog line, which can itself
+// be structured.
+func handleSyslogMsg(msg []byte, ev *typesv1.Log, jsonHandler *JSONHandler, logfmtHandler *LogfmtHandler) {
+	// RFC 5424 allows a BOM to signal UTF-8
+	msg = bytes.TrimPrefix(msg, []byte("\xef\xbb\xbf"))
+	structured := bytes.TrimPrefix(bytes.TrimPrefix(msg, []byte("@cee:")), []byte(" "))
+	if jsonHandler.TryHandle(structured, ev) || logfmtHandler.TryHandle(msg, ev) {
+		return
+	}
+	ev.Body = string(msg)
+}
+
+// applySyslogHeader fills what the MSG didn't tell with what the syslog
+// header says.
+func applySyslogHeader(ev *typesv1.Log, pri []byte, ts time.Time, hostname, appName, procID []byte) {
+	if ev.Timestamp == nil && !ts.IsZero() {
+		ev.Timestamp = timestamppb.New(ts)
+	}
+	if len(pri) > 0 {
+		if p, err := strconv.Atoi(string(pri)); err == nil && p < len(syslogFacilities)*8 {
+			severity := syslogSeverities[p%8]
+			if ev.SeverityText == "" {
+				ev.SeverityText = severity.text
+				ev.SeverityNumber = severity.number
+			}
+			ev.Attributes = append(ev.Attributes, typesv1.KeyVal("syslog.facility", typesv1.ValStr(syslogFacilities[p/8])))
+		}
+	}
+
+	var resAttrs []*typesv1.KV
+	if h := string(hostname); h != syslogNil {
+		resAttrs = append(resAttrs, typesv1.KeyVal(string(semconv.HostNameKey), typesv1.ValStr(h)))
+	}
+	if app := string(appName); app != syslogNil {
+		resAttrs = append(resAttrs, typesv1.KeyVal(string(semconv.ServiceNameKey), typesv1.ValStr(app)))
+		ev.ServiceName = app
+	}
+	if pid := string(procID); pid != syslogNil {
+		if n, err := strconv.ParseInt(pid, 10, 64); err == nil {
+			resAttrs = append(resAttrs, typesv1.KeyVal(string(semconv.ProcessPIDKey), typesv1.ValI64(n)))
+		} else {
+			resAttrs = append(resAttrs, typesv1.KeyVal("syslog.procid", typesv1.ValStr(pid)))
+		}
+	}
+	if len(resAttrs) > 0 {
+		ev.Resource = typesv1.NewResource("", resAttrs)
+	}
+}
+
+// parseRFC3164Time parses the timestamp of a BSD syslog line. They don't
+// say the year, so it's the one closest to `now`.
+func parseRFC3164Time(value string, now time.Time, allowRFC3339 bool) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, allowRFC3339
+	}
+	t, err := time.ParseInLocation(time.Stamp, value, now.Location())
+	if err != nil {
+		return time.Time{}, false
+	}
+	t = t.AddDate(now.Year(), 0, 0)
+	// a log from december read in january
+	if t.After(now.AddDate(0, 1, 0)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t, true
+}
+
+// parseStructuredData parses the STRUCTURED-DATA of an RFC 5424 line, as
+// attributes named `SD-ID.PARAM-NAME`. What follows it is the MSG.
+func parseStructuredData(d []byte) (attrs []*typesv1.KV, msg []byte, ok bool) {
+	if len(d) == 0 {
+		return nil, nil, false
+	}
+	if d[0] == '-' {
+		return nil, bytes.TrimPrefix(d[1:], []byte(" ")), len(d) == 1 || d[1] == ' '
+	}
+	for len(d) > 0 && d[0] == '[' {
+		end := bytes.IndexAny(d, " ]")
+		if end < 2 {
+			return nil, nil, false
+		}
+		sdID := string(d[1:end])
+		d = d[end:]
+		for len(d) > 0 && d[0] == ' ' {
+			eq := bytes.IndexByte(d, '=')
+			if eq < 2 || len(d) < eq+2 || d[eq+1] != '"' {
+				return nil, nil, false
+			}
+			name := string(d[1:eq])
+			value, n, ok := parseSDParamValue(d[eq+2:])
+			if !ok {
+				return nil, nil, false
+			}
+			attrs = append(attrs, typesv1.KeyVal(sdID+"."+name, typesv1.ValStr(value)))
+			d = d[eq+2+n:]
+		}
+		if len(d) == 0 || d[0] != ']' {
+			return nil, nil, false
+		}
+		d = d[1:]
+	}
+	if len(d) > 0 && d[0] != ' ' {
+		return nil, nil, false
+	}
+	return attrs, bytes.TrimPrefix(d, []byte(" ")), true
+}
+
+// parseSDParamValue reads a PARAM-VALUE up to its closing quote, which is
+// consumed. In values, `"`, `\` and `]` are escaped with a backslash.
+func parseSDParamValue(d []byte) (string, int, bool) {
+	var value []byte
+	for i := 0; i < len(d); i++ {
+		switch d[i] {
+		case '\\':
+			if i+1 < len(d) && (d[i+1] == '"' || d[i+1] == '\\' || d[i+1] == ']') {
+				i++
+			}
+			value = append(value, d[i])
+		case '"':
+			return string(value), i + 1, true
+		default:
+			value = append(value, d[i])
+		}
+	}
+	return "", 0, false
+}
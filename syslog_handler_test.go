@@ -0,0 +1,117 @@
+package humanlog
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"github.com/humanlogio/humanlog/pkg/sink/bufsink"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestSyslog(t *testing.T) {
+	now := time.Date(2024, 10, 11, 15, 25, 6, 0, time.UTC)
+	tests := []struct {
+		name  string
+		input string
+		want  *typesv1.Log
+	}{
+		{
+			name:  "rfc5424 with structured data",
+			input: `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 [exampleSDID@32473 iut="3" eventSource="Application"][examplePriority@32473 class="high \"q\\ [x\]"] An application event log entry...`,
+			want: &typesv1.Log{
+				Timestamp:      timestamppb.New(time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC)),
+				SeverityText:   "info",
+				SeverityNumber: 10,
+				ServiceName:    "evntslog",
+				Body:           "An application event log entry...",
+				Resource: typesv1.NewResource("", []*typesv1.KV{
+					typesv1.KeyVal("host.name", typesv1.ValStr("mymachine.example.com")),
+					typesv1.KeyVal("service.name", typesv1.ValStr("evntslog")),
+					typesv1.KeyVal("process.pid", typesv1.ValI64(1234)),
+				}),
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("syslog.facility", typesv1.ValStr("local4")),
+					typesv1.KeyVal("syslog.msgid", typesv1.ValStr("ID47")),
+					typesv1.KeyVal("exampleSDID@32473.iut", typesv1.ValStr("3")),
+					typesv1.KeyVal("exampleSDID@32473.eventSource", typesv1.ValStr("Application")),
+					typesv1.KeyVal("examplePriority@32473.class", typesv1.ValStr(`high "q\ [x]`)),
+				},
+			},
+		},
+		{
+			name:  "rfc5424 with nil values and a json msg",
+			input: `<11>1 - - - - - - @cee: {"msg":"disk full","level":"warn","disk":"/dev/sda1"}`,
+			want: &typesv1.Log{
+				SeverityText:   "warn",
+				SeverityNumber: 13,
+				Body:           "disk full",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("disk", typesv1.ValStr("/dev/sda1")),
+					typesv1.KeyVal("syslog.facility", typesv1.ValStr("user")),
+				},
+			},
+		},
+		{
+			name:  "rfc3164",
+			input: `<34>Oct 11 22:14:15 mymachine su[230]: 'su root' failed for lonvick on /dev/pts/8`,
+			want: &typesv1.Log{
+				Timestamp:      timestamppb.New(time.Date(2024, 10, 11, 22, 14, 15, 0, time.UTC)),
+				SeverityText:   "fatal",
+				SeverityNumber: 21,
+				ServiceName:    "su",
+				Body:           "'su root' failed for lonvick on /dev/pts/8",
+				Resource: typesv1.NewResource("", []*typesv1.KV{
+					typesv1.KeyVal("host.name", typesv1.ValStr("mymachine")),
+					typesv1.KeyVal("service.name", typesv1.ValStr("su")),
+					typesv1.KeyVal("process.pid", typesv1.ValI64(230)),
+				}),
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("syslog.facility", typesv1.ValStr("auth")),
+				},
+			},
+		},
+		{
+			name:  "rfc3164 from last year, without PRI, with a logfmt msg",
+			input: `Dec 31 23:59:59 web nginx: msg="reloading" workers=4`,
+			want: &typesv1.Log{
+				Timestamp:   timestamppb.New(time.Date(2023, 12, 31, 23, 59, 59, 0, time.UTC)),
+				ServiceName: "nginx",
+				Body:        "reloading",
+				Resource: typesv1.NewResource("", []*typesv1.KV{
+					typesv1.KeyVal("host.name", typesv1.ValStr("web")),
+					typesv1.KeyVal("service.name", typesv1.ValStr("nginx")),
+				}),
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("workers", typesv1.ValI64(4)),
+				},
+			},
+		},
+		{
+			name:  "not syslog without PRI",
+			input: `2024-10-11T15:25:06Z INFO main: starting`,
+			want:  &typesv1.Log{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.newULID = func() *typesv1.ULID { return nil }
+			opts.timeNow = func() time.Time { return now }
+
+			sink := bufsink.NewSizedBufferedSink(100, nil)
+			require.NoError(t, Scan(context.Background(), strings.NewReader(tt.input), sink, opts))
+			require.Len(t, sink.Buffered, 1)
+
+			tt.want.ObservedTimestamp = timestamppb.New(now)
+			tt.want.Raw = []byte(tt.input)
+			diff := cmp.Diff(tt.want, sink.Buffered[0], protocmp.Transform())
+			require.Empty(t, diff)
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package humanlog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"github.com/humanlogio/humanlog/pkg/sink"
+	"github.com/humanlogio/humanlog/pkg/sink/syncsink"
+)
+
+// maxSyslogMessageSize is the largest UDP datagram.
+const maxSyslogMessageSize = 64 * 1024
+
+// ServeSyslog acts as a syslog server, receiving messages over UDP on
+// `udpAddr` and over TCP on `tcpAddr`, and parsing them like `Scan` does.
+// Either address can be empty to not listen on that transport. It runs
+// until `ctx` is done.
+func ServeSyslog(ctx context.Context, udpAddr, tcpAddr string, snk sink.Sink, opts *HandlerOptions) error {
+	if udpAddr == "" && tcpAddr == "" {
+		return fmt.Errorf("no address to listen on")
+	}
+	snk = syncsink.NewSyncSink(snk)
+
+	var (
+		udpConn net.PacketConn
+		tcpL    net.Listener
+		err     error
+	)
+	if udpAddr != "" {
+		udpConn, err = net.ListenPacket("udp", udpAddr)
+		if err != nil {
+			return fmt.Errorf("listening for syslog over UDP: %v", err)
+		}
+		defer udpConn.Close()
+	}
+	if tcpAddr != "" {
+		tcpL, err = net.Listen("tcp", tcpAddr)
+		if err != nil {
+			return fmt.Errorf("listening for syslog over TCP: %v", err)
+		}
+		defer tcpL.Close()
+	}
+	return serveSyslog(ctx, udpConn, tcpL, snk, opts)
+}
+
+func serveSyslog(ctx context.Context, udpConn net.PacketConn, tcpL net.Listener, snk sink.Sink, opts *HandlerOptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errc := make(chan error, 2)
+	if udpConn != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errc <- serveSyslogUDP(ctx, udpConn, snk, opts)
+		}()
+	}
+	if tcpL != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errc <- serveSyslogTCP(ctx, tcpL, snk, opts)
+		}()
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-errc:
+	}
+	// unblock the reads and accepts
+	cancel()
+	if udpConn != nil {
+		_ = udpConn.Close()
+	}
+	if tcpL != nil {
+		_ = tcpL.Close()
+	}
+	wg.Wait()
+	return err
+}
+
+func serveSyslogUDP(ctx context.Context, conn net.PacketConn, snk sink.Sink, opts *HandlerOptions) error {
+	parse := newLineParser(opts)
+	ev := new(typesv1.Log)
+	buf := make([]byte, maxSyslogMessageSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("reading syslog over UDP: %v", err)
+		}
+		msg := trimSyslogMessage(buf[:n])
+		if len(msg) == 0 {
+			continue
+		}
+		if !parse(msg, ev) {
+			continue
+		}
+		if err := snk.Receive(ctx, ev); err != nil {
+			return err
+		}
+	}
+}
+
+func serveSyslogTCP(ctx context.Context, l net.Listener, snk sink.Sink, opts *HandlerOptions) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	// closes the open connections before waiting on them
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("accepting syslog connection: %v", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+			done := make(chan struct{})
+			defer close(done)
+			go func() {
+				select {
+				case <-ctx.Done():
+					_ = conn.Close()
+				case <-done:
+				}
+			}()
+			// a broken stream only ends its own connection, senders
+			// will reconnect
+			_ = readSyslogStream(ctx, conn, snk, opts)
+		}()
+	}
+}
+
+// readSyslogStream reads the messages sent over a TCP stream, framed
+// either with octet counting or with newlines (RFC 6587). Senders use
+// octet counting when a message starts with its length.
+func readSyslogStream(ctx context.Context, r io.Reader, snk sink.Sink, opts *HandlerOptions) error {
+	parse := newLineParser(opts)
+	ev := new(typesv1.Log)
+	br := bufio.NewReaderSize(r, maxSyslogMessageSize)
+	for {
+		msg, err := readSyslogFrame(br)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		msg = trimSyslogMessage(msg)
+		if len(msg) == 0 {
+			continue
+		}
+		if !parse(msg, ev) {
+			continue
+		}
+		if err := snk.Receive(ctx, ev); err != nil {
+			return err
+		}
+	}
+}
+
+func readSyslogFrame(br *bufio.Reader) ([]byte, error) {
+	if n, prefixLen, ok := peekOctetCount(br); ok {
+		if _, err := br.Discard(prefixLen); err != nil {
+			return nil, err
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(br, msg); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		return msg, nil
+	}
+	msg, err := br.ReadSlice('\n')
+	for errors.Is(err, bufio.ErrBufferFull) {
+		// too long to be a legit message, skip it
+		_, err = br.ReadSlice('\n')
+		msg = nil
+	}
+	if err == io.EOF && len(msg) > 0 {
+		return msg, nil
+	}
+	return msg, err
+}
+
+// peekOctetCount tells if the next frame starts with a `MSG-LEN SP`
+// prefix, and how long that prefix is. The prefix must be followed by
+// the `<` that starts the PRI of the message, so that newline framed
+// messages starting with a number aren't mistaken for counted ones.
+func peekOctetCount(br *bufio.Reader) (n, prefixLen int, ok bool) {
+	// peek one byte at a time, so as to not wait on bytes that a newline
+	// framed message might never send
+	maxDigits := len(strconv.Itoa(maxBufferSize))
+	for i := 1; i <= maxDigits+1; i++ {
+		head, err := br.Peek(i)
+		if err != nil {
+			return 0, 0, false
+		}
+		c := head[i-1]
+		switch {
+		case c >= '1' && c <= '9', c == '0' && i > 1:
+			continue
+		case c == ' ' && i > 1:
+			n, err := strconv.Atoi(string(head[:i-1]))
+			if err != nil || n > maxBufferSize {
+				return 0, 0, false
+			}
+			if head, err = br.Peek(i + 1); err != nil || head[i] != '<' {
+				return 0, 0, false
+			}
+			return n, i, true
+		}
+		return 0, 0, false
+	}
+	return 0, 0, false
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// trimSyslogMessage removes the trailing newline and NUL bytes that
+// some senders add to messages.
+func trimSyslogMessage(msg []byte) []byte {
+	return bytes.TrimRight(msg, "\r\n\x00")
+}
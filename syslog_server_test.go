@@ -0,0 +1,95 @@
+package humanlog
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+type chanSink chan *typesv1.Log
+
+func (c chanSink) Receive(ctx context.Context, ev *typesv1.Log) error {
+	c <- proto.Clone(ev).(*typesv1.Log)
+	return nil
+}
+
+func (c chanSink) Close(ctx context.Context) error { return nil }
+
+func TestServeSyslog(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	udpConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	tcpL, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	opts := DefaultOptions()
+	opts.newULID = func() *typesv1.ULID { return nil }
+	snk := make(chanSink, 10)
+	errc := make(chan error, 1)
+	go func() { errc <- serveSyslog(ctx, udpConn, tcpL, snk, opts) }()
+
+	next := func() *typesv1.Log {
+		select {
+		case ev := <-snk:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a message")
+			return nil
+		}
+	}
+
+	udp, err := net.Dial("udp", udpConn.LocalAddr().String())
+	require.NoError(t, err)
+	defer udp.Close()
+	_, err = udp.Write([]byte("<34>Oct 11 22:14:15 mymachine su[230]: over udp\n"))
+	require.NoError(t, err)
+	ev := next()
+	require.Equal(t, "over udp", ev.Body)
+	require.Equal(t, "su", ev.ServiceName)
+
+	tcp, err := net.Dial("tcp", tcpL.Addr().String())
+	require.NoError(t, err)
+	defer tcp.Close()
+	framed := "<165>1 - host app - - - octet\ncounted"
+	_, err = tcp.Write([]byte(strings.Join([]string{
+		strconv.Itoa(len(framed)) + " " + framed,
+		"<14>1 - host app - - - newline framed\n",
+		"2024-10-11 starts with digits\n",
+	}, "")))
+	require.NoError(t, err)
+	require.Equal(t, "octet\ncounted", next().Body)
+	require.Equal(t, "newline framed", next().Body)
+	require.Equal(t, "2024-10-11 starts with digits", string(next().Raw))
+
+	cancel()
+	select {
+	case err := <-errc:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server didn't stop")
+	}
+}
+
+func TestReadSyslogFrame(t *testing.T) {
+	// `123 foo` isn't followed by a PRI, so it isn't counted
+	br := bufio.NewReaderSize(strings.NewReader("5 <1>hi7 <2>abc\n123 foo\nlast"), maxSyslogMessageSize)
+	var got []string
+	for {
+		msg, err := readSyslogFrame(br)
+		if err != nil {
+			break
+		}
+		got = append(got, string(trimSyslogMessage(msg)))
+	}
+	require.Equal(t, []string{"<1>hi", "<2>abc", "123 foo", "last"}, got)
+}
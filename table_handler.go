@@ -0,0 +1,243 @@
+package humanlog
+
+import (
+	"bytes"
+	"encoding/csv"
+	"regexp"
+	"slices"
+	"strings"
+
+	typesv1 "github.com/minitape/api/go/types/v1"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// W3C extended log files (IIS, among others) start with directives that
+// describe them, the `#Fields:` one naming the space separated columns
+// of the lines that follow. Directives are repeated when the server
+// restarts.
+var (
+	w3cDirectivePrefixes = [][]byte{
+		[]byte("#Version:"), []byte("#Software:"), []byte("#Date:"),
+		[]byte("#Start-Date:"), []byte("#End-Date:"), []byte("#Remark:"),
+	}
+	w3cFieldsPrefix = []byte("#Fields:")
+)
+
+// w3cFields maps the columns of W3C extended log files to the OTel
+// attributes they are recorded as.
+var w3cFields = map[string]string{
+	"c-ip":            string(semconv.ClientAddressKey),
+	"s-ip":            string(semconv.ServerAddressKey),
+	"s-port":          string(semconv.ServerPortKey),
+	"cs-method":       string(semconv.HTTPRequestMethodKey),
+	"cs-uri-stem":     string(semconv.URLPathKey),
+	"cs-uri-query":    string(semconv.URLQueryKey),
+	"sc-status":       string(semconv.HTTPResponseStatusCodeKey),
+	"sc-bytes":        string(semconv.HTTPResponseBodySizeKey),
+	"cs-bytes":        string(semconv.HTTPRequestBodySizeKey),
+	"cs-username":     string(semconv.UserNameKey),
+	"cs-host":         "http.request.header.host",
+	"cs(User-Agent)":  string(semconv.UserAgentOriginalKey),
+	"cs(Referer)":     "http.request.header.referer",
+	"cs-version":      string(semconv.NetworkProtocolVersionKey),
+	"sc-substatus":    "iis.substatus",
+	"sc-win32-status": "iis.win32_status",
+}
+
+// tableHandler parses the rows of CSV, TSV and W3C extended log files,
+// once it knows their columns. The columns are either given, or learned
+// from a `#Fields:` directive or from a header on the first line.
+type tableHandler struct {
+	opts *HandlerOptions
+
+	columns []string
+	// delim separates the columns, 0 until it's known
+	delim rune
+	w3c   bool
+	lines int
+}
+
+func newTableHandler(opts *HandlerOptions) *tableHandler {
+	return &tableHandler{opts: opts, columns: opts.Columns, delim: opts.ColumnDelimiter}
+}
+
+// delims are the column delimiters that might be used.
+func (h *tableHandler) delims() []rune {
+	if h.opts.ColumnDelimiter != 0 {
+		return []rune{h.opts.ColumnDelimiter}
+	}
+	return []rune{',', '\t'}
+}
+
+// TryHandle parses a row into `ev`. Headers and directives are handled
+// without completing `ev`, since they aren't events.
+func (h *tableHandler) TryHandle(d []byte, ev *typesv1.Log) (handled, complete bool) {
+	h.lines++
+	if len(d) > 0 && d[0] == '#' {
+		if fields, ok := bytes.CutPrefix(d, w3cFieldsPrefix); ok {
+			h.columns = strings.Fields(string(fields))
+			h.delim = ' '
+			h.w3c = true
+			return true, false
+		}
+		for _, prefix := range w3cDirectivePrefixes {
+			if bytes.HasPrefix(d, prefix) {
+				return true, false
+			}
+		}
+		return false, false
+	}
+	if h.columns == nil {
+		if h.lines == 1 && h.learnHeader(d) {
+			return true, false
+		}
+		return false, false
+	}
+	if looksStructured(d) {
+		// rows of given columns could be mistaken for these when they
+		// hold commas or tabs
+		return false, false
+	}
+	fields, ok := h.split(d)
+	if !ok {
+		return false, false
+	}
+	if slices.Equal(fields, h.columns) {
+		// the header, repeated
+		return true, false
+	}
+	h.fill(ev, fields)
+	return true, true
+}
+
+// learnHeader tells if `d` is a header, which it is if it names columns
+// and one of them is a time, level or message field.
+func (h *tableHandler) learnHeader(d []byte) bool {
+next_delim:
+	for _, delim := range h.delims() {
+		fields, ok := splitRow(d, delim, -1)
+		if !ok || len(fields) < 2 {
+			continue
+		}
+		known := false
+		for _, field := range fields {
+			if !isColumnName(field) {
+				continue next_delim
+			}
+			known = known || isOneOf(field, h.opts.TimeFields) || isOneOf(field, h.opts.LevelFields) || isOneOf(field, h.opts.MessageFields)
+		}
+		if known {
+			h.columns = fields
+			h.delim = delim
+			return true
+		}
+	}
+	return false
+}
+
+func (h *tableHandler) split(d []byte) ([]string, bool) {
+	if h.w3c {
+		fields := strings.Fields(string(d))
+		return fields, len(fields) == len(h.columns)
+	}
+	if h.delim != 0 {
+		return splitRow(d, h.delim, len(h.columns))
+	}
+	// columns were given, but not how they're separated
+	for _, delim := range h.delims() {
+		if fields, ok := splitRow(d, delim, len(h.columns)); ok {
+			h.delim = delim
+			return fields, true
+		}
+	}
+	return nil, false
+}
+
+// logfmtStartRe matches the first key of a logfmt line.
+var logfmtStartRe = regexp.MustCompile(`^[\w.@-]+=`)
+
+// looksStructured tells if `d` looks like JSON or logfmt, which other
+// handlers parse.
+func looksStructured(d []byte) bool {
+	d = bytes.TrimSpace(d)
+	return bytes.HasPrefix(d, []byte("{")) || logfmtStartRe.Match(d)
+}
+
+func splitRow(d []byte, delim rune, n int) ([]string, bool) {
+	r := csv.NewReader(bytes.NewReader(d))
+	r.Comma = delim
+	r.FieldsPerRecord = n
+	r.LazyQuotes = true
+	fields, err := r.Read()
+	if err != nil {
+		return nil, false
+	}
+	return fields, true
+}
+
+func isColumnName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '_', c == '-', c == '.', c == '@', c == ' ', c == '(', c == ')':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (h *tableHandler) fill(ev *typesv1.Log, fields []string) {
+	var date string
+	if h.w3c {
+		// W3C logs have the date and the time in separate columns
+		if i := slices.Index(h.columns, "date"); i >= 0 {
+			date = fields[i]
+		}
+	}
+	for i, column := range h.columns {
+		val := fields[i]
+		if val == "" || (h.w3c && val == "-") {
+			continue
+		}
+		if h.w3c && column == "date" {
+			continue
+		}
+		if h.w3c && column == "time" && date != "" {
+			val = date + " " + val
+		}
+		if ev.Timestamp == nil && (isOneOf(column, h.opts.TimeFields) || h.w3c && column == "time") {
+			if t, ok := tryParseTimeString(val); ok {
+				ev.Timestamp = timestamppb.New(t)
+				continue
+			}
+		}
+		if ev.Body == "" && isOneOf(column, h.opts.MessageFields) {
+			ev.Body = val
+			continue
+		}
+		if ev.SeverityText == "" && isOneOf(column, h.opts.LevelFields) {
+			ev.SeverityText = val
+			continue
+		}
+		key := column
+		if h.w3c {
+			if name, ok := w3cFields[column]; ok {
+				key = name
+			}
+		}
+		attr := typesv1.ValStr(val)
+		if key == string(semconv.HTTPResponseStatusCodeKey) {
+			attr = typedPatternValue(val, "int")
+		}
+		ev.Attributes = append(ev.Attributes, typesv1.KeyVal(key, attr))
+	}
+	if h.w3c {
+		// W3C logs are access logs
+		finishHTTPAccess(ev)
+	}
+}
@@ -0,0 +1,142 @@
+package humanlog
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/humanlogio/humanlog/pkg/sink/bufsink"
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestTables(t *testing.T) {
+	now := time.Date(2024, 11, 16, 10, 0, 0, 0, time.UTC)
+	ts := timestamppb.New(time.Date(2024, 11, 12, 16, 35, 3, 907700000, time.UTC))
+	tests := []struct {
+		name    string
+		columns []string
+		delim   rune
+		input   string
+		want    []*typesv1.Log
+	}{
+		{
+			name:  "csv with a header",
+			input: "time,level,message,user\n2024-11-12 16:35:03.9077,INFO,\"hello, world\",bob",
+			want: []*typesv1.Log{
+				{
+					Timestamp:      ts,
+					SeverityText:   "INFO",
+					SeverityNumber: 9,
+					Body:           "hello, world",
+					Attributes: []*typesv1.KV{
+						typesv1.KeyVal("user", typesv1.ValStr("bob")),
+					},
+				},
+			},
+		},
+		{
+			name:  "tsv with a header",
+			input: "ts\tlvl\tmsg\n2024-11-12 16:35:03.9077\twarn\thello",
+			want: []*typesv1.Log{
+				{Timestamp: ts, SeverityText: "warn", SeverityNumber: 13, Body: "hello"},
+			},
+		},
+		{
+			name:    "given columns",
+			columns: []string{"time", "level", "logger", "message", "exception"},
+			input:   "2024-11-12 16:35:03.9077,ERROR,MyApp.Repository,Operation failed,System.ArgumentException: nope",
+			want: []*typesv1.Log{
+				{
+					Timestamp:      ts,
+					SeverityText:   "ERROR",
+					SeverityNumber: 17,
+					Body:           "Operation failed",
+					Attributes: []*typesv1.KV{
+						typesv1.KeyVal("logger", typesv1.ValStr("MyApp.Repository")),
+						typesv1.KeyVal("exception", typesv1.ValStr("System.ArgumentException: nope")),
+					},
+				},
+			},
+		},
+		{
+			name:  "given delimiter",
+			delim: ';',
+			input: "time;level;message\n2024-11-12 16:35:03.9077;INFO;hello, world",
+			want: []*typesv1.Log{
+				{Timestamp: ts, SeverityText: "INFO", SeverityNumber: 9, Body: "hello, world"},
+			},
+		},
+		{
+			name: "w3c",
+			input: `#Software: Microsoft Internet Information Services 10.0
+#Version: 1.0
+#Date: 2024-11-12 16:35:03
+#Fields: date time s-ip cs-method cs-uri-stem cs-uri-query s-port cs-username c-ip cs(User-Agent) sc-status time-taken
+2024-11-12 16:35:03.9077 10.0.0.1 GET /index.html - 443 - 203.0.113.7 Mozilla/5.0 404 15`,
+			want: []*typesv1.Log{
+				{
+					Timestamp:      ts,
+					SeverityText:   "warn",
+					SeverityNumber: 13,
+					Body:           "GET /index.html",
+					Attributes: []*typesv1.KV{
+						typesv1.KeyVal("server.address", typesv1.ValStr("10.0.0.1")),
+						typesv1.KeyVal("http.request.method", typesv1.ValStr("GET")),
+						typesv1.KeyVal("url.path", typesv1.ValStr("/index.html")),
+						typesv1.KeyVal("server.port", typesv1.ValStr("443")),
+						typesv1.KeyVal("client.address", typesv1.ValStr("203.0.113.7")),
+						typesv1.KeyVal("user_agent.original", typesv1.ValStr("Mozilla/5.0")),
+						typesv1.KeyVal("http.response.status_code", typesv1.ValI64(404)),
+						typesv1.KeyVal("time-taken", typesv1.ValStr("15")),
+					},
+				},
+			},
+		},
+		{
+			name:    "given columns leave json and logfmt alone",
+			columns: []string{"level", "message"},
+			input:   "{\"level\":\"info\",\"msg\":\"json\"}\nlevel=warn msg=\"a, b\"\nERROR,from csv\nhello, world, again",
+			want: []*typesv1.Log{
+				{SeverityText: "info", SeverityNumber: 9, Body: "json"},
+				{SeverityText: "warn", SeverityNumber: 13, Body: "a, b"},
+				{SeverityText: "ERROR", SeverityNumber: 17, Body: "from csv"},
+				{Raw: []byte("hello, world, again")},
+			},
+		},
+		{
+			name:  "text with commas isn't a header",
+			input: "hello, world\nlevel=info msg=hi",
+			want: []*typesv1.Log{
+				{Raw: []byte("hello, world")},
+				{Raw: []byte("level=info msg=hi"), SeverityText: "info", SeverityNumber: 9, Body: "hi"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Columns = tt.columns
+			opts.ColumnDelimiter = tt.delim
+			opts.newULID = func() *typesv1.ULID { return nil }
+			opts.timeNow = func() time.Time { return now }
+
+			sink := bufsink.NewSizedBufferedSink(100, nil)
+			require.NoError(t, Scan(context.Background(), strings.NewReader(tt.input), sink, opts))
+
+			require.Len(t, sink.Buffered, len(tt.want))
+			for i, want := range tt.want {
+				want.ObservedTimestamp = timestamppb.New(now)
+				if want.Raw == nil {
+					want.Raw = sink.Buffered[i].Raw
+				}
+				diff := cmp.Diff(want, sink.Buffered[i], protocmp.Transform())
+				require.Empty(t, diff, "log %d", i)
+			}
+		})
+	}
+}
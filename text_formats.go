@@ -0,0 +1,195 @@
+package humanlog
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	typesv1 "github.com/minitape/api/go/types/v1"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+)
+
+// textFormatDefinitions are sub-patterns shared by the built-in text
+// formats.
+var textFormatDefinitions = map[string]string{
+	"FILENAME": `[^\s:\[\]]+`,
+	"MODULE":   `\w+(?:::\w+)*`,
+	// HTTPREQUEST is the request line of HTTP/1, or whatever garbage was
+	// sent instead
+	"HTTPREQUEST": `%{WORD:http.request.method} %{URIPATH:url.path}(?:\?%{NOTSPACE:url.query})?(?: HTTP/%{NOTSPACE:network.protocol.version})?|%{DATA:http.request.original}`,
+}
+
+var glogLevels = map[string]string{
+	"I": "info",
+	"W": "warn",
+	"E": "error",
+	"F": "fatal",
+}
+
+// textFormats are the default layouts of popular logging libraries. Their
+// patterns are kept strict, since text lines can look like anything.
+var textFormats = []*Pattern{
+	// glog and klog:
+	//	I0102 15:04:05.123456    1234 file.go:12] msg
+	mustCompileTextFormat(&Pattern{Name: "glog", TimeLayout: "0102 15:04:05.999999", Levels: glogLevels},
+		`(?P<level>[IWEF])(?P<time>\d{4} \d{2}:\d{2}:\d{2}\.\d+)\s+%{NONNEGINT:thread.id:int} %{FILENAME:code.file.path}:%{NONNEGINT:code.line.number:int}\] %{GREEDYDATA:msg}`),
+
+	// log4j2 and logback `PatternLayout`s, with an optional caller or
+	// MDC after the logger:
+	//	2024-01-02 15:04:05,123 [main] INFO  org.example.App - msg
+	//	2024-01-02 15:04:05.123 [main] INFO  org.example.App [App.java:12] - msg
+	mustCompileTextFormat(&Pattern{Name: "log4j"},
+		`%{TIMESTAMP_ISO8601:time} \[%{DATA:thread.name}\] %{LOGLEVEL:level}\s+%{JAVACLASS:logger}(?: \[(?:%{FILENAME:code.file.path}:%{NONNEGINT:code.line.number:int}|%{DATA:mdc})\])? - %{GREEDYDATA:msg}`),
+	// logback's default layout only has the time of day:
+	//	15:04:05.123 [main] INFO  org.example.App - msg
+	mustCompileTextFormat(&Pattern{Name: "logback", TimeLayout: "15:04:05"},
+		`(?P<time>\d{2}:\d{2}:\d{2}\.\d{3}) \[%{DATA:thread.name}\] %{LOGLEVEL:level}\s+%{JAVACLASS:logger}(?: \[(?:%{FILENAME:code.file.path}:%{NONNEGINT:code.line.number:int}|%{DATA:mdc})\])? - %{GREEDYDATA:msg}`),
+
+	// Python's logging, with `basicConfig`'s default format and the
+	// `asctime - name - levelname - message` one from its cookbook:
+	//	WARNING:root:msg
+	//	2024-01-02 15:04:05,123 - app - WARNING - msg
+	mustCompileTextFormat(&Pattern{Name: "python"},
+		`(?P<level>DEBUG|INFO|WARNING|ERROR|CRITICAL):(?P<logger>[\w.]+):%{GREEDYDATA:msg}`),
+	mustCompileTextFormat(&Pattern{Name: "python-cookbook"},
+		`%{TIMESTAMP_ISO8601:time} - (?P<logger>[\w.]+) - (?P<level>DEBUG|INFO|WARNING|ERROR|CRITICAL) - %{GREEDYDATA:msg}`),
+	// loguru:
+	//	2024-01-02 15:04:05.123 | INFO     | app:main:12 - msg
+	mustCompileTextFormat(&Pattern{Name: "loguru"},
+		`%{TIMESTAMP_ISO8601:time} \|\s*(?P<level>[A-Z]+)\s*\| %{FILENAME:code.file.path}:%{FILENAME:code.function.name}:%{NONNEGINT:code.line.number:int} - %{GREEDYDATA:msg}`),
+
+	// Ruby's Logger:
+	//	I, [2024-01-02T15:04:05.123456 #1234]  INFO -- progname: msg
+	mustCompileTextFormat(&Pattern{Name: "ruby"},
+		`[DIWEFAU], \[%{TIMESTAMP_ISO8601:time} #%{NONNEGINT:process.pid:int}\]\s+(?P<level>[A-Z]+) -- (?:%{DATA:logger})?: %{GREEDYDATA:msg}`),
+
+	// spdlog, with or without a logger name:
+	//	[2024-01-02 15:04:05.123] [name] [info] msg
+	mustCompileTextFormat(&Pattern{Name: "spdlog"},
+		`\[%{TIMESTAMP_ISO8601:time}\] (?:\[%{DATA:logger}\] )?\[%{LOGLEVEL:level}\] %{GREEDYDATA:msg}`),
+
+	// log4rs, with its default encoder and with the file and line:
+	//	2024-01-02T15:04:05.123456+00:00 INFO app::db - msg
+	//	2024-01-02T15:04:05.123456+00:00 [main.rs:12] INFO   app::db:msg
+	mustCompileTextFormat(&Pattern{Name: "log4rs"},
+		`%{TIMESTAMP_ISO8601:time} %{LOGLEVEL:level} %{MODULE:logger} - %{GREEDYDATA:msg}`),
+	mustCompileTextFormat(&Pattern{Name: "log4rs-caller"},
+		`%{TIMESTAMP_ISO8601:time} \[%{FILENAME:code.file.path}:%{NONNEGINT:code.line.number:int}\] %{LOGLEVEL:level}\s+%{MODULE:logger}:%{GREEDYDATA:msg}`),
+
+	// NLog's default layout:
+	//	2024-01-02 15:04:05.1234|INFO|MyApp.Program|msg
+	mustCompileTextFormat(&Pattern{Name: "nlog"},
+		`%{TIMESTAMP_ISO8601:time}\|%{LOGLEVEL:level}\|%{JAVACLASS:logger}\|%{GREEDYDATA:msg}`),
+
+	// gin's access lines:
+	//	[GIN] 2024/01/02 - 15:04:05 | 200 |     953.2µs |       127.0.0.1 | GET      "/path"
+	mustCompileTextFormat(&Pattern{Name: "gin", TimeLayout: "2006/01/02 - 15:04:05", finish: finishHTTPAccess},
+		`\[GIN\] (?P<time>\d{4}/\d{2}/\d{2} - \d{2}:\d{2}:\d{2}) \|\s*%{NONNEGINT:http.response.status_code:int}\s*\|\s*%{NOTSPACE:latency}\s*\|\s*%{IPORHOST:client.address}\s*\|\s*%{WORD:http.request.method}\s*(?:"%{DATA:url.path}"|\| %{NOTSPACE:url.path}(?: \|%{DATA:error.message})?(?: \| %{NONNEGINT:http.response.body.size:int} bytes)?(?: \| %{GREEDYDATA:user_agent.original})?)`),
+
+	// the Common and Combined Log Formats of Apache and nginx:
+	//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 2326 "http://ref/" "Mozilla/5.0"
+	mustCompileTextFormat(&Pattern{Name: "access-log", TimeLayout: "02/Jan/2006:15:04:05 -0700", finish: finishHTTPAccess},
+		`%{IPORHOST:client.address} %{NOTSPACE} (?:-|%{NOTSPACE:user.name}) \[(?P<time>%{HTTPDATE})\] "%{HTTPREQUEST}" %{NONNEGINT:http.response.status_code:int} (?:-|%{NONNEGINT:http.response.body.size:int})(?: "(?:-|%{DATA:http.request.header.referer})" "(?:-|%{DATA:user_agent.original})")?`),
+	// nginx's error.log, which often ends with what it knows of the
+	// request:
+	//	2024/01/02 15:04:05 [error] 1234#0: *5 open() "/x" failed, client: 127.0.0.1, server: localhost, request: "GET /x HTTP/1.1"
+	mustCompileTextFormat(&Pattern{Name: "nginx-error", TimeLayout: "2006/01/02 15:04:05", finish: finishNginxError},
+		`(?P<time>\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}) \[%{LOGLEVEL:level}\] %{NONNEGINT:process.pid:int}#%{NONNEGINT:thread.id:int}: (?:\*%{NONNEGINT:nginx.connection.id:int} )?%{GREEDYDATA:msg}`),
+}
+
+// DefaultTextFormats are the text formats humanlog knows about.
+var DefaultTextFormats = func() []*Pattern {
+	return append([]*Pattern(nil), textFormats...)
+}
+
+func mustCompileTextFormat(format *Pattern, pattern string) *Pattern {
+	p, err := CompilePattern(format.Name, pattern, textFormatDefinitions)
+	if err != nil {
+		panic(fmt.Sprintf("text format %q: %v", format.Name, err))
+	}
+	p.TimeLayout = format.TimeLayout
+	p.Levels = format.Levels
+	p.finish = format.finish
+	return p
+}
+
+// finishHTTPAccess describes access log lines with their request, and
+// with the level their status calls for.
+func finishHTTPAccess(ev *typesv1.Log) {
+	var method, path string
+	var status int64
+	for _, kv := range ev.Attributes {
+		switch kv.Key {
+		case string(semconv.HTTPRequestMethodKey):
+			method = kv.Value.GetStr()
+		case string(semconv.URLPathKey):
+			path = kv.Value.GetStr()
+		case string(semconv.HTTPResponseStatusCodeKey):
+			status = kv.Value.GetI64()
+		}
+	}
+	ev.Body = strings.TrimSpace(method + " " + path)
+	ev.SeverityText = levelForStatus(status)
+}
+
+func levelForStatus(status int64) string {
+	switch {
+	case status >= 500:
+		return "error"
+	case status >= 400:
+		return "warn"
+	default:
+		return "info"
+	}
+}
+
+// nginxErrorContextRe matches the details that nginx appends to its
+// error messages, like `, client: 127.0.0.1`.
+var nginxErrorContextRe = regexp.MustCompile(`, (client|server|request|upstream|host|referrer): ("(?:[^"\\]|\\.)*"|[^,]*)`)
+
+var nginxErrorContextKeys = map[string]string{
+	"client":   string(semconv.ClientAddressKey),
+	"server":   string(semconv.ServerAddressKey),
+	"upstream": "nginx.upstream",
+	"host":     "http.request.header.host",
+	"referrer": "http.request.header.referer",
+}
+
+// nginxRequestRe matches the request line that nginx quotes.
+var nginxRequestRe = regexp.MustCompile(`^(\S+) ([^\s?]+)(?:\?(\S*))?(?: HTTP/(\S+))?$`)
+
+// finishNginxError moves the details of nginx's error messages into
+// attributes.
+func finishNginxError(ev *typesv1.Log) {
+	loc := nginxErrorContextRe.FindStringIndex(ev.Body)
+	if loc == nil {
+		return
+	}
+	for _, m := range nginxErrorContextRe.FindAllStringSubmatch(ev.Body[loc[0]:], -1) {
+		name, value := m[1], m[2]
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		if name != "request" {
+			ev.Attributes = append(ev.Attributes, typesv1.KeyVal(nginxErrorContextKeys[name], typesv1.ValStr(value)))
+			continue
+		}
+		req := nginxRequestRe.FindStringSubmatch(value)
+		if req == nil {
+			ev.Attributes = append(ev.Attributes, typesv1.KeyVal("http.request.original", typesv1.ValStr(value)))
+			continue
+		}
+		ev.Attributes = append(ev.Attributes,
+			typesv1.KeyVal(string(semconv.HTTPRequestMethodKey), typesv1.ValStr(req[1])),
+			typesv1.KeyVal(string(semconv.URLPathKey), typesv1.ValStr(req[2])),
+		)
+		if req[3] != "" {
+			ev.Attributes = append(ev.Attributes, typesv1.KeyVal(string(semconv.URLQueryKey), typesv1.ValStr(req[3])))
+		}
+		if req[4] != "" {
+			ev.Attributes = append(ev.Attributes, typesv1.KeyVal(string(semconv.NetworkProtocolVersionKey), typesv1.ValStr(req[4])))
+		}
+	}
+	ev.Body = ev.Body[:loc[0]]
+}
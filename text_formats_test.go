@@ -0,0 +1,239 @@
+package humanlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestTextFormats(t *testing.T) {
+	now := time.Date(2024, 11, 16, 10, 0, 0, 0, time.UTC)
+	ts := func(year int, month time.Month, day, hour, min, sec, nsec int) *timestamppb.Timestamp {
+		return timestamppb.New(time.Date(year, month, day, hour, min, sec, nsec, time.UTC))
+	}
+	tests := []struct {
+		name  string
+		input string
+		want  *typesv1.Log
+	}{
+		{
+			name:  "glog",
+			input: `I1115 21:24:58.438419  8740 auth_manager.cc:440] Processed request in 598ms`,
+			want: &typesv1.Log{
+				Timestamp:    ts(2024, 11, 15, 21, 24, 58, 438419000),
+				SeverityText: "info",
+				Body:         "Processed request in 598ms",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("thread.id", typesv1.ValI64(8740)),
+					typesv1.KeyVal("code.file.path", typesv1.ValStr("auth_manager.cc")),
+					typesv1.KeyVal("code.line.number", typesv1.ValI64(440)),
+				},
+			},
+		},
+		{
+			name:  "log4j2",
+			input: `2024-11-15 19:01:52,286 [main] WARN  o.e.r.ProductRepository - High resource usage`,
+			want: &typesv1.Log{
+				Timestamp:    ts(2024, 11, 15, 19, 1, 52, 286000000),
+				SeverityText: "WARN",
+				Body:         "High resource usage",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("thread.name", typesv1.ValStr("main")),
+					typesv1.KeyVal("logger", typesv1.ValStr("o.e.r.ProductRepository")),
+				},
+			},
+		},
+		{
+			name:  "logback with caller",
+			input: `2024-11-15 18:44:08.699 [scheduler-1] INFO  org.example.App [App.java:286] - Request processed`,
+			want: &typesv1.Log{
+				Timestamp:    ts(2024, 11, 15, 18, 44, 8, 699000000),
+				SeverityText: "INFO",
+				Body:         "Request processed",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("thread.name", typesv1.ValStr("scheduler-1")),
+					typesv1.KeyVal("logger", typesv1.ValStr("org.example.App")),
+					typesv1.KeyVal("code.file.path", typesv1.ValStr("App.java")),
+					typesv1.KeyVal("code.line.number", typesv1.ValI64(286)),
+				},
+			},
+		},
+		{
+			name:  "logback default layout",
+			input: `09:43:32.782 [background-processor-1] ERROR org.example.UserService - Failed to process request`,
+			want: &typesv1.Log{
+				Timestamp:    ts(2024, 11, 16, 9, 43, 32, 782000000),
+				SeverityText: "ERROR",
+				Body:         "Failed to process request",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("thread.name", typesv1.ValStr("background-processor-1")),
+					typesv1.KeyVal("logger", typesv1.ValStr("org.example.UserService")),
+				},
+			},
+		},
+		{
+			name:  "python logging",
+			input: `WARNING:app.db:connection lost`,
+			want: &typesv1.Log{
+				SeverityText: "WARNING",
+				Body:         "connection lost",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("logger", typesv1.ValStr("app.db")),
+				},
+			},
+		},
+		{
+			name:  "loguru",
+			input: `2024-11-15 22:05:58.318 |     INFO | app_4.py:function_8:457 - Request processed`,
+			want: &typesv1.Log{
+				Timestamp:    ts(2024, 11, 15, 22, 5, 58, 318000000),
+				SeverityText: "INFO",
+				Body:         "Request processed",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("code.file.path", typesv1.ValStr("app_4.py")),
+					typesv1.KeyVal("code.function.name", typesv1.ValStr("function_8")),
+					typesv1.KeyVal("code.line.number", typesv1.ValI64(457)),
+				},
+			},
+		},
+		{
+			name:  "ruby logger",
+			input: `W, [2024-11-15T19:26:10.564 #66620]  WARN -- JobProcessor: High resource usage`,
+			want: &typesv1.Log{
+				Timestamp:    ts(2024, 11, 15, 19, 26, 10, 564000000),
+				SeverityText: "WARN",
+				Body:         "High resource usage",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("process.pid", typesv1.ValI64(66620)),
+					typesv1.KeyVal("logger", typesv1.ValStr("JobProcessor")),
+				},
+			},
+		},
+		{
+			name:  "spdlog",
+			input: `[2024-11-15 20:30:14.077] [db_logger] [info] Request processed`,
+			want: &typesv1.Log{
+				Timestamp:    ts(2024, 11, 15, 20, 30, 14, 77000000),
+				SeverityText: "info",
+				Body:         "Request processed",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("logger", typesv1.ValStr("db_logger")),
+				},
+			},
+		},
+		{
+			name:  "log4rs",
+			input: `2024-11-15T12:58:03.582453+00:00 [main.rs:338] INFO   app::db:request processed`,
+			want: &typesv1.Log{
+				Timestamp:    ts(2024, 11, 15, 12, 58, 3, 582453000),
+				SeverityText: "INFO",
+				Body:         "request processed",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("code.file.path", typesv1.ValStr("main.rs")),
+					typesv1.KeyVal("code.line.number", typesv1.ValI64(338)),
+					typesv1.KeyVal("logger", typesv1.ValStr("app::db")),
+				},
+			},
+		},
+		{
+			name:  "nlog",
+			input: `2024-11-12 16:34:39.2302|FATAL|MyApp.Data.Repository|Operation failed`,
+			want: &typesv1.Log{
+				Timestamp:    ts(2024, 11, 12, 16, 34, 39, 230200000),
+				SeverityText: "FATAL",
+				Body:         "Operation failed",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("logger", typesv1.ValStr("MyApp.Data.Repository")),
+				},
+			},
+		},
+		{
+			name:  "gin",
+			input: `[GIN] 2024/11/12 - 15:19:06 | 404 |     953.2µs |       127.0.0.1 | GET      "/api/v1/users"`,
+			want: &typesv1.Log{
+				Timestamp:    ts(2024, 11, 12, 15, 19, 6, 0),
+				SeverityText: "warn",
+				Body:         "GET /api/v1/users",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("http.response.status_code", typesv1.ValI64(404)),
+					typesv1.KeyVal("latency", typesv1.ValStr("953.2µs")),
+					typesv1.KeyVal("client.address", typesv1.ValStr("127.0.0.1")),
+					typesv1.KeyVal("http.request.method", typesv1.ValStr("GET")),
+					typesv1.KeyVal("url.path", typesv1.ValStr("/api/v1/users")),
+				},
+			},
+		},
+		{
+			name:  "combined log format",
+			input: `203.0.113.7 - frank [10/Oct/2024:13:55:36 -0700] "GET /a.gif?size=2 HTTP/1.1" 503 2326 "http://example.com/" "Mozilla/5.0"`,
+			want: &typesv1.Log{
+				Timestamp:    ts(2024, 10, 10, 20, 55, 36, 0),
+				SeverityText: "error",
+				Body:         "GET /a.gif",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("client.address", typesv1.ValStr("203.0.113.7")),
+					typesv1.KeyVal("user.name", typesv1.ValStr("frank")),
+					typesv1.KeyVal("http.request.method", typesv1.ValStr("GET")),
+					typesv1.KeyVal("url.path", typesv1.ValStr("/a.gif")),
+					typesv1.KeyVal("url.query", typesv1.ValStr("size=2")),
+					typesv1.KeyVal("network.protocol.version", typesv1.ValStr("1.1")),
+					typesv1.KeyVal("http.response.status_code", typesv1.ValI64(503)),
+					typesv1.KeyVal("http.response.body.size", typesv1.ValI64(2326)),
+					typesv1.KeyVal("http.request.header.referer", typesv1.ValStr("http://example.com/")),
+					typesv1.KeyVal("user_agent.original", typesv1.ValStr("Mozilla/5.0")),
+				},
+			},
+		},
+		{
+			name:  "common log format",
+			input: `::1 - - [10/Oct/2024:13:55:36 +0000] "\x16\x03\x01" 400 -`,
+			want: &typesv1.Log{
+				Timestamp:    ts(2024, 10, 10, 13, 55, 36, 0),
+				SeverityText: "warn",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("client.address", typesv1.ValStr("::1")),
+					typesv1.KeyVal("http.request.original", typesv1.ValStr(`\x16\x03\x01`)),
+					typesv1.KeyVal("http.response.status_code", typesv1.ValI64(400)),
+				},
+			},
+		},
+		{
+			name:  "nginx error.log",
+			input: `2024/10/10 13:55:36 [error] 31#31: *7 open() "/usr/share/nginx/html/x" failed (2: No such file or directory), client: 172.17.0.1, server: localhost, request: "GET /x?y=1 HTTP/1.1", host: "localhost:8080"`,
+			want: &typesv1.Log{
+				Timestamp:    ts(2024, 10, 10, 13, 55, 36, 0),
+				SeverityText: "error",
+				Body:         `open() "/usr/share/nginx/html/x" failed (2: No such file or directory)`,
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("process.pid", typesv1.ValI64(31)),
+					typesv1.KeyVal("thread.id", typesv1.ValI64(31)),
+					typesv1.KeyVal("nginx.connection.id", typesv1.ValI64(7)),
+					typesv1.KeyVal("client.address", typesv1.ValStr("172.17.0.1")),
+					typesv1.KeyVal("server.address", typesv1.ValStr("localhost")),
+					typesv1.KeyVal("http.request.method", typesv1.ValStr("GET")),
+					typesv1.KeyVal("url.path", typesv1.ValStr("/x")),
+					typesv1.KeyVal("url.query", typesv1.ValStr("y=1")),
+					typesv1.KeyVal("network.protocol.version", typesv1.ValStr("1.1")),
+					typesv1.KeyVal("http.request.header.host", typesv1.ValStr("localhost:8080")),
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.timeNow = func() time.Time { return now }
+
+			got := new(typesv1.Log)
+			handled, _ := newHandlerChain(opts)([]byte(tt.input), got)
+			require.True(t, handled)
+			diff := cmp.Diff(tt.want, got, protocmp.Transform())
+			require.Empty(t, diff)
+		})
+	}
+}
@@ -2,7 +2,9 @@ package humanlog
 
 import (
 	"encoding/json"
+	"slices"
 	"strconv"
+	"sync/atomic"
 	"time"
 )
 
@@ -108,7 +110,9 @@ func tryParseTime(value any) (time.Time, bool) {
 	return t, false
 }
 
-var timeParsers = func() []timeParserFn {
+// timeParsers are shared by all the scans, which may run at the same
+// time, so they're reordered on a copy that replaces them.
+var timeParsers = func() *atomic.Pointer[[]timeParserFn] {
 	var out []timeParserFn
 	// parse time standard Go time formats
 	for _, layout := range TimeFormats {
@@ -116,16 +120,20 @@ var timeParsers = func() []timeParserFn {
 	}
 	// then try to parse other types of strings
 	out = append(out, timeParserForF64)
-	return out
+	var p atomic.Pointer[[]timeParserFn]
+	p.Store(&out)
+	return &p
 }()
 
 func tryParseTimeString(v string) (time.Time, bool) {
 	var t time.Time
-	for i, parser := range timeParsers {
+	parsers := *timeParsers.Load()
+	for i, parser := range parsers {
 		t, ok := parser(v)
 		if ok {
-			if dynamicReordering {
-				timeParsers = moveToFront(i, timeParsers)
+			if dynamicReordering && i != 0 {
+				reordered := moveToFront(i, slices.Clone(parsers))
+				timeParsers.Store(&reordered)
 			}
 			t = fixTimebeforeUnixZero(t)
 			return t, true
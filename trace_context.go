@@ -0,0 +1,226 @@
+package humanlog
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	typesv1 "github.com/minitape/api/go/types/v1"
+)
+
+// traceparentRe matches W3C `traceparent` headers:
+//
+//	00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+var traceparentRe = regexp.MustCompile(`^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})(?:-.*)?$`)
+
+// traceContext is what a log tells of the trace it was emitted in.
+type traceContext struct {
+	traceID  []byte
+	spanID   []byte
+	flags    uint32
+	hasFlags bool
+}
+
+// extractTraceContext moves the trace and span IDs found in the
+// attributes of `ev` to its trace context.
+func extractTraceContext(ev *typesv1.Log, opts *HandlerOptions) {
+	if ev.TraceId != nil {
+		return
+	}
+	var (
+		tc       traceContext
+		consumed = make(map[int]struct{})
+	)
+	for i, kv := range ev.Attributes {
+		switch {
+		case tc.traceID == nil && isOneOf(kv.Key, opts.TraceParentFields):
+			if parsed, ok := parseTraceHeader(kv.Value.GetStr()); ok {
+				tc.traceID = parsed.traceID
+				if tc.spanID == nil {
+					tc.spanID = parsed.spanID
+				}
+				if !tc.hasFlags {
+					tc.flags, tc.hasFlags = parsed.flags, parsed.hasFlags
+				}
+				consumed[i] = struct{}{}
+			}
+		case tc.traceID == nil && isOneOf(kv.Key, opts.TraceIDFields):
+			if id, ok := parseTraceID(kv.Value, isOneOf(kv.Key, opts.DecimalIDFields)); ok {
+				tc.traceID = id
+				consumed[i] = struct{}{}
+			}
+		case tc.spanID == nil && isOneOf(kv.Key, opts.SpanIDFields):
+			if id, ok := parseSpanID(kv.Value, isOneOf(kv.Key, opts.DecimalIDFields)); ok {
+				tc.spanID = id
+				consumed[i] = struct{}{}
+			}
+		case !tc.hasFlags && isOneOf(kv.Key, opts.TraceFlagsFields):
+			if flags, ok := parseTraceFlags(kv.Value); ok {
+				tc.flags, tc.hasFlags = flags, true
+				consumed[i] = struct{}{}
+			}
+		}
+	}
+	if tc.traceID == nil {
+		// spans and flags mean nothing without their trace
+		return
+	}
+	ev.TraceId = typesv1.TraceIDFromBytesSlice(nil, tc.traceID)
+	if tc.spanID != nil {
+		ev.SpanId = typesv1.SpanIDFromBytesSlice(nil, tc.spanID)
+	}
+	ev.TraceFlags = tc.flags
+	attrs := ev.Attributes[:0]
+	for i, kv := range ev.Attributes {
+		if _, ok := consumed[i]; !ok {
+			attrs = append(attrs, kv)
+		}
+	}
+	ev.Attributes = attrs
+}
+
+// parseTraceHeader parses the headers that propagate a trace context:
+// W3C's `traceparent` and AWS X-Ray's `X-Amzn-Trace-Id`.
+func parseTraceHeader(v string) (traceContext, bool) {
+	v = strings.TrimSpace(v)
+	if m := traceparentRe.FindStringSubmatch(strings.ToLower(v)); m != nil {
+		if m[1] == "ff" {
+			// an invalid version
+			return traceContext{}, false
+		}
+		traceID, _ := hex.DecodeString(m[2])
+		spanID, _ := hex.DecodeString(m[3])
+		flags, _ := strconv.ParseUint(m[4], 16, 8)
+		if isZero(traceID) {
+			return traceContext{}, false
+		}
+		if isZero(spanID) {
+			spanID = nil
+		}
+		return traceContext{traceID: traceID, spanID: spanID, flags: uint32(flags), hasFlags: true}, true
+	}
+	// Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1
+	var tc traceContext
+	for _, part := range strings.Split(v, ";") {
+		k, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "Root":
+			version, id, _ := strings.Cut(val, "-")
+			id = strings.ReplaceAll(id, "-", "")
+			if version != "1" || len(id) != 32 {
+				return traceContext{}, false
+			}
+			traceID, err := hex.DecodeString(id)
+			if err != nil || isZero(traceID) {
+				return traceContext{}, false
+			}
+			tc.traceID = traceID
+		case "Parent":
+			if spanID, err := hex.DecodeString(val); err == nil && len(spanID) == 8 && !isZero(spanID) {
+				tc.spanID = spanID
+			}
+		case "Sampled":
+			switch val {
+			case "1":
+				tc.flags, tc.hasFlags = 1, true
+			case "0":
+				tc.flags, tc.hasFlags = 0, true
+			}
+		}
+	}
+	return tc, tc.traceID != nil
+}
+
+// parseTraceID parses trace IDs written in hex, with 128 or 64 bits, or
+// in decimal like Datadog does. IDs of 64 bits are padded with zeros.
+func parseTraceID(v *typesv1.Val, decimal bool) ([]byte, bool) {
+	id, ok := parseID(v, 16, decimal)
+	if !ok || len(id) == 16 {
+		return id, ok
+	}
+	return append(make([]byte, 8), id...), true
+}
+
+// parseSpanID parses span IDs written in hex or in decimal.
+func parseSpanID(v *typesv1.Val, decimal bool) ([]byte, bool) {
+	id, ok := parseID(v, 8, decimal)
+	if !ok || len(id) != 8 {
+		return nil, false
+	}
+	return id, true
+}
+
+// parseID parses an ID of at most `size` bytes, or of 8 bytes when
+// written in decimal. Strings are only read as decimal if `decimal` is
+// set.
+func parseID(v *typesv1.Val, size int, decimal bool) ([]byte, bool) {
+	var id []byte
+	switch k := v.GetKind().(type) {
+	case *typesv1.Val_I64:
+		id = binary.BigEndian.AppendUint64(nil, uint64(k.I64))
+	case *typesv1.Val_F64:
+		// floats only hold integers exactly up to 2^53, past that the
+		// id has already lost digits
+		if k.F64 >= 0 && k.F64 < 1<<53 && k.F64 == math.Trunc(k.F64) {
+			id = binary.BigEndian.AppendUint64(nil, uint64(k.F64))
+		}
+	case *typesv1.Val_Str:
+		if decimal {
+			if n, err := strconv.ParseUint(strings.TrimSpace(k.Str), 10, 64); err == nil {
+				id = binary.BigEndian.AppendUint64(nil, n)
+			}
+			break
+		}
+		s := strings.TrimSpace(k.Str)
+		if len(s) == 36 {
+			// like a UUID
+			s = strings.ReplaceAll(s, "-", "")
+		}
+		if (len(s) == 16 || len(s) == 2*size) && isHex(s) {
+			id, _ = hex.DecodeString(s)
+		} else if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+			id = binary.BigEndian.AppendUint64(nil, n)
+		}
+	}
+	if id == nil || isZero(id) {
+		return nil, false
+	}
+	return id, true
+}
+
+func parseTraceFlags(v *typesv1.Val) (uint32, bool) {
+	switch k := v.GetKind().(type) {
+	case *typesv1.Val_I64:
+		return uint32(k.I64) & 0xff, k.I64 >= 0 && k.I64 <= 0xff
+	case *typesv1.Val_Bool:
+		if k.Bool {
+			return 1, true
+		}
+		return 0, true
+	case *typesv1.Val_Str:
+		n, err := strconv.ParseUint(k.Str, 16, 8)
+		return uint32(n), err == nil
+	}
+	return 0, false
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+func isZero(id []byte) bool {
+	for _, b := range id {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,148 @@
+package humanlog
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	typesv1 "github.com/minitape/api/go/types/v1"
+	"github.com/humanlogio/humanlog/pkg/sink/bufsink"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestTraceContext(t *testing.T) {
+	now := time.Date(2024, 10, 11, 15, 25, 6, 0, time.UTC)
+	traceID := func(h string) *typesv1.TraceID {
+		id, err := typesv1.TraceIDFromHex(nil, h)
+		require.NoError(t, err)
+		return id
+	}
+	spanID := func(h string) *typesv1.SpanID {
+		id, err := typesv1.SpanIDFromHex(nil, h)
+		require.NoError(t, err)
+		return id
+	}
+	tests := []struct {
+		name  string
+		input string
+		want  *typesv1.Log
+	}{
+		{
+			name:  "hex ids",
+			input: `{"msg":"hello","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","user":42}`,
+			want: &typesv1.Log{
+				Body:    "hello",
+				TraceId: traceID("4bf92f3577b34da6a3ce929d0e0e4736"),
+				SpanId:  spanID("00f067aa0ba902b7"),
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("user", typesv1.ValI64(42)),
+				},
+			},
+		},
+		{
+			name:  "traceparent",
+			input: `traceparent=00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01 msg=hello`,
+			want: &typesv1.Log{
+				Body:       "hello",
+				TraceId:    traceID("4bf92f3577b34da6a3ce929d0e0e4736"),
+				SpanId:     spanID("00f067aa0ba902b7"),
+				TraceFlags: 1,
+			},
+		},
+		{
+			name:  "datadog decimal ids",
+			input: `{"msg":"hello","dd.trace_id":"5208512171318403364","dd.span_id":7164301593186347531}`,
+			want: &typesv1.Log{
+				Body:    "hello",
+				TraceId: traceID("000000000000000048485a3953bb6124"),
+				SpanId:  spanID("636cb65745a32a0b"),
+			},
+		},
+		{
+			name:  "datadog ids of 16 digits",
+			input: `{"msg":"hello","dd.trace_id":"1234567890123456","dd.span_id":"1234567890123456"}`,
+			want: &typesv1.Log{
+				Body:    "hello",
+				TraceId: traceID("0000000000000000000462d53c8abac0"),
+				SpanId:  spanID("000462d53c8abac0"),
+			},
+		},
+		{
+			name:  "ids parsed as floats",
+			input: `{"msg":"hello","dd.trace_id":1e15}`,
+			want: &typesv1.Log{
+				Body:    "hello",
+				TraceId: traceID("000000000000000000038d7ea4c68000"),
+			},
+		},
+		{
+			name:  "ids too large for a float to hold",
+			input: `{"msg":"hello","dd.trace_id":9223372036854775809}`,
+			want: &typesv1.Log{
+				Body: "hello",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("dd.trace_id", typesv1.ValF64(9223372036854775809)),
+				},
+			},
+		},
+		{
+			name:  "x-ray",
+			input: `{"msg":"hello","X-Amzn-Trace-Id":"Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"}`,
+			want: &typesv1.Log{
+				Body:       "hello",
+				TraceId:    traceID("5759e988bd862e3fe1be46a994272793"),
+				SpanId:     spanID("53995c3f42cd8ad8"),
+				TraceFlags: 1,
+			},
+		},
+		{
+			name:  "64 bits hex trace id",
+			input: `{"msg":"hello","traceId":"a3ce929d0e0e4736"}`,
+			want: &typesv1.Log{
+				Body:    "hello",
+				TraceId: traceID("0000000000000000a3ce929d0e0e4736"),
+			},
+		},
+		{
+			name:  "span without a trace",
+			input: `{"msg":"hello","span_id":"00f067aa0ba902b7"}`,
+			want: &typesv1.Log{
+				Body: "hello",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("span_id", typesv1.ValStr("00f067aa0ba902b7")),
+				},
+			},
+		},
+		{
+			name:  "invalid ids",
+			input: `{"msg":"hello","trace_id":"00000000000000000000000000000000","traceparent":"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}`,
+			want: &typesv1.Log{
+				Body: "hello",
+				Attributes: []*typesv1.KV{
+					typesv1.KeyVal("trace_id", typesv1.ValStr("00000000000000000000000000000000")),
+					typesv1.KeyVal("traceparent", typesv1.ValStr("ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")),
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.newULID = func() *typesv1.ULID { return nil }
+			opts.timeNow = func() time.Time { return now }
+
+			sink := bufsink.NewSizedBufferedSink(100, nil)
+			require.NoError(t, Scan(context.Background(), strings.NewReader(tt.input), sink, opts))
+
+			require.Len(t, sink.Buffered, 1)
+			tt.want.Raw = []byte(tt.input)
+			tt.want.ObservedTimestamp = timestamppb.New(now)
+			diff := cmp.Diff(tt.want, sink.Buffered[0], protocmp.Transform())
+			require.Empty(t, diff)
+		})
+	}
+}